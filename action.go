@@ -0,0 +1,45 @@
+package airbrake
+
+import (
+	"path"
+	"strings"
+)
+
+// ActionFunc infers the component and action of the notices which have
+// neither, e.g. of errors outside of HTTP handlers, from their top
+// application frame, so that errbit can group and display them usefully.
+// Set it to nil to leave them empty.
+var ActionFunc = InferAction
+
+// InferAction is the default ActionFunc: the component is the name of the
+// package of the frame, and the action that of the function, with its
+// receiver type if it is a method, e.g. models and Invoice.Total.
+func InferAction(frame Line) (component, action string) {
+	action = frame.Name
+	if frame.Receiver != "" {
+		action = strings.TrimPrefix(frame.Receiver, "*") + "." + action
+	}
+	return path.Base(frame.Package), action
+}
+
+// inferAction sets the component and action of the notice from its top
+// application frame, unless either is already set.
+func (n *Notifier) inferAction(notice *Notice) {
+	if n.actionFunc == nil {
+		return
+	}
+	if r := notice.Request; r != nil && (r.Component != "" || r.Action != "") {
+		return
+	}
+	for _, line := range notice.Error.Backtrace {
+		if line.Dependency || line.Package == "" || strings.HasPrefix(line.Package, "runtime") ||
+			stdlib(line.Package+"."+line.Name, line.File) {
+			continue
+		}
+		if component, action := n.actionFunc(line); component != "" || action != "" {
+			r := notice.request()
+			r.Component, r.Action = component, action
+		}
+		return
+	}
+}
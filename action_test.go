@@ -0,0 +1,31 @@
+package airbrake
+
+import "testing"
+
+func TestInferAction(t *testing.T) {
+	backtrace := []Line{
+		newLine("net/http.(*Client).Do", goroot+"net/http/client.go", 587),
+		{Function: "pq.(*conn).query", Package: "github.com/lib/pq", Receiver: "*conn", Name: "query", Dependency: true},
+		newLine("github.com/user/project/models.(*Invoice).Total", "models/invoice.go", 42),
+	}
+
+	n := NewNotifier("key")
+	notice := &Notice{Error: errorInfo{Backtrace: backtrace}}
+	n.inferAction(notice)
+	if r := notice.Request; r == nil || r.Component != "models" || r.Action != "Invoice.Total" {
+		t.Errorf("unexpected request: %+v", r)
+	}
+
+	notice = &Notice{Error: errorInfo{Backtrace: backtrace}, Request: &request{Component: "job"}}
+	n.inferAction(notice)
+	if notice.Request.Action != "" {
+		t.Errorf("expected the component to be kept: %+v", notice.Request)
+	}
+
+	WithActionFunc(func(frame Line) (string, string) { return "billing", frame.Name })(n)
+	notice = &Notice{Error: errorInfo{Backtrace: backtrace}}
+	n.inferAction(notice)
+	if r := notice.Request; r.Component != "billing" || r.Action != "Total" {
+		t.Errorf("unexpected request: %+v", r)
+	}
+}
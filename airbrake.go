@@ -1,17 +1,17 @@
 package airbrake
 
 import (
-	"bytes"
+	"context"
+	"crypto/tls"
 	"errors"
-	"io/ioutil"
-	"log"
+	"fmt"
+	"net"
 	"net/http"
-	"os"
-	"reflect"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
-	"text/template"
+	"time"
 )
 
 var (
@@ -20,6 +20,18 @@ var (
 	Environment = "development"
 	Verbose     = false
 
+	// DiagnosticsInterval logs the payload and response of a notice at most
+	// once per interval, for insight into production, where Verbose would
+	// be too noisy. DiagnoseFailures logs the payloads of the notices whose
+	// delivery failed. Filters can also set Notice.Debug to log a notice.
+	DiagnosticsInterval = time.Duration(0)
+	DiagnoseFailures    = false
+
+	// EnvironmentKeys maps environment names to API keys, so that a binary
+	// promoted from staging to production reports to the matching project,
+	// based on the Environment setting. ApiKey is used for unlisted environments.
+	EnvironmentKeys map[string]string
+
 	// PrettyParams allows including request query/form parameters on the Environment tab
 	// which is more readable than the raw text of the Parameters tab (in Errbit).
 	// The param keys will be rendered as "?<param>" so they will sort together at the top of the tab.
@@ -30,8 +42,16 @@ var (
 	// any file paths in the backtrace that contain that string will be converted
 	// to the `[PROJECT_ROOT]/...` form, which triggers the hyperlinking in errbit.
 	// This feature also requires the APP to have its Repository configured in errbit.
+	// If unset, the directory of the main module is found from the build info,
+	// which also works for -trimpath builds.
 	RootPackage = ""
 
+	// RootPackages maps further root packages, e.g. those of the other modules
+	// of a monorepo, to their directory in the repository, so that their frames
+	// are hyperlinked too, e.g. github.com/user/mono/billing to billing, whose
+	// files become [PROJECT_ROOT]/billing/... The longest matching root wins.
+	RootPackages map[string]string
+
 	// AppVersion determines which commit will be used for backtrace hyperlinks.
 	// If unset, errbit defaults to `master`. For github it should be a branch name
 	// or a commit hash.
@@ -39,44 +59,307 @@ var (
 	// is to use the -X linker flag. (see https://golang.org/cmd/ld)
 	AppVersion = ""
 
+	// CaptureGoroutines makes CapturePanic attach the stacks of all goroutines
+	// to the notice, as the GOROUTINES entry of the Environment tab (in Errbit).
+	// Useful for debugging deadlocks, but the dump can be large.
+	CaptureGoroutines = false
+
+	// WrapPanics makes CapturePanic and NotifyOnExit re-panic with a
+	// *ReportedPanic wrapping the recovered value, which the other capture
+	// helpers don't report again. Disable it for recovery layers that
+	// inspect the recovered value, or use ReportedPanic.Value.
+	WrapPanics = true
+
+	// EnvironmentVariables lists the environment variables, e.g. REGION or POD_NAME,
+	// whose values are included on the Environment tab (in Errbit) of every notice.
+	// Variables with sensitive names or empty values are omitted.
+	EnvironmentVariables []string
+
+	// Tags are added to every notice, as the tag.<name> params, e.g. the team
+	// owning the service or the region it runs in. See NotifyWithTags.
+	Tags map[string]string
+
+	// CaptureMemStats includes a snapshot of the heap size, memory obtained from
+	// the OS, GC count and last GC pause in the params of every notice.
+	CaptureMemStats = false
+
+	// CaptureKubernetes includes the pod name, namespace, node and container ID
+	// on the Environment tab (in Errbit), when running in a Kubernetes pod,
+	// so errors map to specific pods rather than ephemeral hostnames.
+	CaptureKubernetes = false
+
+	// CaptureLabels includes the profiler labels of the context of the request,
+	// or of NotifyContext, set with pprof.Do or pprof.WithLabels, in the params
+	// as label.<key>, e.g. to tell which worker pool reported an error.
+	CaptureLabels = false
+
+	// RequestIDHeader names the request header carrying the correlation ID,
+	// whose value is included as the request_id param of the notice.
+	RequestIDHeader = "X-Request-Id"
+
+	// HeaderAllowlist switches to sending only the listed request headers,
+	// e.g. User-Agent and Referer, instead of all headers but the sensitive ones.
+	// Sensitive headers are omitted even if listed.
+	HeaderAllowlist []string
+
+	// ParseJSONBody enables including the fields of application/json request
+	// bodies on the Parameters tab, the same way as query/form parameters.
+	// The body is restored afterwards, so handlers can still read it.
+	ParseJSONBody = false
+
+	// TrustedProxies are the networks of the proxies whose X-Forwarded-For,
+	// X-Forwarded-Proto and X-Forwarded-Host headers are trusted. If nil,
+	// proxies on loopback and private networks are.
+	TrustedProxies []*net.IPNet
+
+	// AbsoluteURL reports the URL of the requests with the scheme and host
+	// the client used, as forwarded by trusted proxies, so that it can be
+	// followed, rather than only the path.
+	AbsoluteURL = false
+
+	// NoticeSerializer renders the notices, e.g. XML, JSON or a TemplateSerializer.
+	NoticeSerializer = XML
+
+	// NoticeResponseDecoder interprets the answers of the endpoint,
+	// see ResponseDecoder.
+	NoticeResponseDecoder ResponseDecoder = DecodeResponse
+
+	// NoticeSender, if set, delivers the notices instead of posting them to
+	// the endpoint, see Sender.
+	NoticeSender Sender
+
+	// PayloadLimits truncates oversized notices. By default they are unlimited.
+	PayloadLimits = Limits{}
+
+	// MinimalFrames, if positive, switches to minimal notices, of a few
+	// hundred bytes, for constrained environments: only the class, message,
+	// request URL and that many frames of the backtrace are sent, after the
+	// filters have run.
+	MinimalFrames = 0
+
+	// SplitErrors makes errors.Join (and other Unwrap() []error) values be
+	// reported as one notice per constituent error. Otherwise a single notice
+	// is sent, listing the constituent errors in its params.
+	SplitErrors = false
+
+	// NotifyClientErrors makes StatusHandler report 4xx responses as warnings,
+	// in addition to reporting 5xx responses as errors.
+	NotifyClientErrors = false
+
+	// ServerErrors makes ErrorLog report the log lines of the server other
+	// than panics, like TLS handshake errors, as ServerError warnings. They
+	// are mostly noise from scanners and probes, so only panics are reported
+	// by default.
+	ServerErrors = false
+
+	// ScrubURL, if set, rewrites the reported URLs, e.g. ScrubIDs replaces
+	// the IDs in /users/18273/orders/991 to group the errors by route.
+	ScrubURL func(url string) string
+
+	// KeepRawURL adds the URL as it was before ScrubURL as the raw_url param.
+	KeepRawURL = false
+
+	// ScrubValues are the patterns of sensitive values, e.g. SensitiveValues,
+	// which are replaced with [FILTERED] in the messages, params and headers
+	// of the notices, after the filters have run.
+	ScrubValues []*regexp.Regexp
+
+	// UserAgent is sent with the notices, if set, e.g. for proxies or
+	// ingress rules that require identifying clients.
+	UserAgent = ""
+
+	// DeliveryHeaders are added to the requests posting the notices,
+	// e.g. authentication for a proxy in front of the endpoint.
+	DeliveryHeaders http.Header
+
+	// BasicAuthUser and BasicAuthPassword are sent with the notices, if set,
+	// e.g. for a self-hosted Errbit behind basic auth.
+	BasicAuthUser     = ""
+	BasicAuthPassword = ""
+
+	// TLSConfig is used to connect to the endpoint, if set, e.g. RootCAs with
+	// the internal CA of a self-hosted Errbit. The transport of the HTTP
+	// client is copied, so the global one is left untouched. A transport
+	// that is not an *http.Transport, e.g. one wrapped for instrumentation,
+	// cannot be copied: it is kept, the TLS config is ignored, and a warning
+	// is logged. Set the TLS config on the wrapped transport instead.
+	TLSConfig *tls.Config
+
+	// DryRun builds, filters and counts the notices, but does not send them,
+	// e.g. to validate the filters in staging. With Verbose, the payloads
+	// are still logged.
+	DryRun = false
+
+	// CollapseStdlib collapses runs of standard library frames in the
+	// backtraces to the first and last of them, e.g. the net/http frames
+	// between a server and its handlers.
+	CollapseStdlib = false
+
+	// IgnoreDisconnects drops errors caused by clients hanging up, like
+	// context.Canceled, broken pipes and connection resets, when the context
+	// of the request was canceled or writing the response failed. When
+	// disabled, and for the disconnects of other peers, e.g. an upstream
+	// service resetting the connection, they are reported as warnings.
+	IgnoreDisconnects = true
+
+	// TimeoutSeverity, if set, is the severity of timeouts, like those of
+	// net.Error or context.DeadlineExceeded, which are usually transient,
+	// e.g. SeverityWarning so that they don't page anyone.
+	TimeoutSeverity = ""
+
+	// TimeoutSampleRate is the fraction of the timeouts to report,
+	// between 0 and 1.
+	TimeoutSampleRate = 1.0
+
+	// BreakerThreshold is the number of consecutive delivery failures after which
+	// notices are dropped with ErrCircuitOpen, instead of making every caller wait
+	// for a failing endpoint. Zero disables the circuit breaker.
+	BreakerThreshold = 5
+
+	// BreakerCooldown is how long the circuit breaker stays open before a single
+	// notice is sent to probe whether the endpoint has recovered.
+	BreakerCooldown = time.Minute
+
+	// QueueSize enables asynchronous delivery: up to QueueSize notices are queued
+	// and posted in the background, so callers don't wait for the endpoint.
+	// When the queue is full, notices are dropped or the caller waits,
+	// depending on QueueFullPolicy. Use Flush before exiting.
+	QueueSize = 0
+
+	// QueueFullPolicy decides what happens when the queue is full.
+	QueueFullPolicy = DropNewest
+
+	// SyncTimeout, with QueueSize, first tries to post each notice
+	// synchronously, for at most that long, and only queues it if that
+	// fails, e.g. because the endpoint is slow or down. Callers get a quick
+	// confirmation in the common case, without losing notices otherwise.
+	// Notices timing out while the endpoint was processing them may be
+	// received twice.
+	SyncTimeout = time.Duration(0)
+
+	// DeadlineMargin is the time left before the deadline of the context of
+	// a request, or of NotifyContext, under which its notice is queued rather
+	// than delivered synchronously, since the delivery would likely not
+	// complete in time, e.g. on the shutdown path. Without QueueSize, a queue
+	// of 100 notices is started for them. Zero disables it.
+	DeadlineMargin = time.Second
+
+	// BatchSize and BatchDelay control how queued notices are coalesced:
+	// up to BatchSize notices, collected for at most BatchDelay,
+	// are posted back to back.
+	BatchSize  = 20
+	BatchDelay = time.Duration(0)
+
+	// DedupWindow suppresses the duplicates of a notice, with the same class,
+	// message and top frame, for that long after it was sent. The next one
+	// sent tells how many there were, in the duplicates param.
+	// Zero disables deduplication.
+	DedupWindow = time.Duration(0)
+
+	// DisabledEnrichers are the names of the built-in enrichers not to run,
+	// e.g. HostnameEnricher, when the hostname is meaningless or sensitive.
+	DisabledEnrichers []string
+
+	// HourlyQuota caps the notices sent per hour, protecting the endpoint
+	// and the bandwidth of the application from error storms. Once exceeded,
+	// a single AirbrakeQuotaExceeded warning is sent, and further notices are
+	// dropped until the hour is over. Zero means unlimited.
+	HourlyQuota = 0
+
+	// RoutesEndpoint enables route performance stats, see RouteHandler.
+	// They are posted to the routes-stats API of Airbrake, e.g.
+	// https://api.airbrake.io/api/v5/projects/123/routes-stats,
+	// every RoutesInterval.
+	RoutesEndpoint = ""
+	RoutesInterval = defaultRoutesInterval
+
 	sensitive     = regexp.MustCompile(`(?i)password|token|secret|key`)
 	badResponse   = errors.New("Bad response")
 	apiKeyMissing = errors.New("Please set the airbrake.ApiKey before doing calls")
-	tmpl          = template.Must(template.New("error").Parse(source))
+	filters       []func(*Notice) *Notice
+	onSuccess     []func(Notice, Response)
+	onFailure     []func(Notice, error)
+	observers     []func(*Notice)
+	extractors    []func(context.Context, *Notice)
+	users         []userExtractor
+	enrichers     []Enricher
+	stdState      = new(state)
 )
 
 type Line struct {
-	Function string
-	File     string
-	Line     int
+	Function string `xml:"method,attr"`
+	File     string `xml:"file,attr"`
+	Line     int    `xml:"number,attr"`
+
+	// Package is the full import path of the function's package, e.g.
+	// github.com/user/project/models, Receiver the receiver type of a
+	// method, e.g. *Invoice, and Name the function name without them,
+	// e.g. Total. They are only sent by the JSON serializers.
+	Package  string `xml:"-"`
+	Receiver string `xml:"-"`
+	Name     string `xml:"-"`
+
+	// Dependency marks frames in the module cache or a vendor directory,
+	// as opposed to the application's own code.
+	Dependency bool `xml:"dependency,attr,omitempty"`
 }
 
-// stack implements Stack, skipping N frames
-func stacktrace(skip int) (lines []Line) {
-	for i := skip; ; i++ {
-		pc, file, line, ok := runtime.Caller(i)
-		if !ok {
-			break
-		}
+// stacktrace captures the backtrace, skipping the first frames, and the frames
+// of this package at the top. With collapse, runs of standard library frames
+// are collapsed to the first and last of them.
+func stacktrace(skip int, collapse bool) (lines []Line) {
+	pcs := pcsPool.Get().(*[]uintptr)
+	defer pcsPool.Put(pcs)
+	n := runtime.Callers(skip+1, *pcs)
+	for n == len(*pcs) {
+		*pcs = make([]uintptr, 2*len(*pcs))
+		n = runtime.Callers(skip+1, *pcs)
+	}
 
-		item := Line{function(pc), locate(file), line}
+	lines = make([]Line, 0, n)
+	run := 0
+	frames := runtime.CallersFrames((*pcs)[:n])
+	for more := n > 0; more; {
+		var frame runtime.Frame
+		frame, more = frames.Next()
+
+		item := newLine(frame.Function, frame.File, frame.Line)
 
 		// ignore panic method
-		if item.Function != "panic" {
+		if item.Function == "panic" || (len(lines) == 0 && library(frame.File)) {
+			continue
+		}
+
+		learnRoot(frame.Function, frame.File)
+
+		if !collapse {
+			lines = append(lines, item)
+		} else if !stdlib(frame.Function, frame.File) {
+			lines = append(lines, item)
+			run = 0
+		} else if run++; run > 2 {
+			lines[len(lines)-1] = item
+		} else {
 			lines = append(lines, item)
 		}
 	}
 	return
 }
 
-// function returns, if possible, the name of the function containing the PC.
-func function(pc uintptr) string {
-	fn := runtime.FuncForPC(pc)
-	if fn == nil {
-		return "???"
-	} else {
-		return shorten(fn.Name())
+// trimPanic drops the frames of the deferred recovery and the runtime's panic
+// machinery, so that a backtrace captured while panicking starts at the line
+// that actually panicked.
+func trimPanic(lines []Line) []Line {
+	for i := len(lines) - 1; i >= 0; i-- {
+		switch lines[i].Function {
+		case "runtime.gopanic", "runtime.sigpanic", "runtime.panicmem":
+			return lines[i+1:]
+		}
+		if strings.HasPrefix(lines[i].Function, "runtime.panic") {
+			return lines[i+1:]
+		}
 	}
+	return lines
 }
 
 func shorten(name string) string {
@@ -93,137 +376,205 @@ func shorten(name string) string {
 	return name
 }
 
-func locate(f string) string {
-	if RootPackage == "" {
-		return f
+// defaultNotifier returns a Notifier configured from the package-level settings.
+func defaultNotifier() *Notifier {
+	n := &Notifier{
+		apiKey:               ApiKey,
+		environmentKeys:      EnvironmentKeys,
+		endpoint:             Endpoint,
+		environment:          Environment,
+		verbose:              Verbose,
+		diagnosticsInterval:  DiagnosticsInterval,
+		diagnoseFailures:     DiagnoseFailures,
+		prettyParams:         PrettyParams,
+		rootPackage:          RootPackage,
+		rootPackages:         sortRoots(RootPackages),
+		appVersion:           AppVersion,
+		captureGoroutines:    CaptureGoroutines,
+		wrapPanics:           WrapPanics,
+		environmentVariables: EnvironmentVariables,
+		tags:                 Tags,
+		captureMemStats:      CaptureMemStats,
+		captureKubernetes:    CaptureKubernetes,
+		captureLabels:        CaptureLabels,
+		requestIDHeader:      RequestIDHeader,
+		headerAllowlist:      HeaderAllowlist,
+		parseJSONBody:        ParseJSONBody,
+		trustedProxies:       TrustedProxies,
+		absoluteURL:          AbsoluteURL,
+		splitErrors:          SplitErrors,
+		notifyClientErrors:   NotifyClientErrors,
+		serverErrors:         ServerErrors,
+		ignoreDisconnects:    IgnoreDisconnects,
+		timeoutSeverity:      TimeoutSeverity,
+		timeoutSampleRate:    TimeoutSampleRate,
+		dryRun:               DryRun,
+		userAgent:            UserAgent,
+		scrubURL:             ScrubURL,
+		keepRawURL:           KeepRawURL,
+		scrubValues:          ScrubValues,
+		deliveryHeaders:      DeliveryHeaders,
+		basicAuthUser:        BasicAuthUser,
+		basicAuthPassword:    BasicAuthPassword,
+		tlsConfig:            TLSConfig,
+		collapseStdlib:       CollapseStdlib,
+		classFunc:            ClassFunc,
+		actionFunc:           ActionFunc,
+		traceContext:         TraceContext,
+		client:               http.DefaultClient,
+		serializer:           NoticeSerializer,
+		decoder:              NoticeResponseDecoder,
+		sender:               NoticeSender,
+		limits:               PayloadLimits,
+		minimalFrames:        MinimalFrames,
+		filters:              filters,
+		onSuccess:            onSuccess,
+		onFailure:            onFailure,
+		observers:            observers,
+		extractors:           extractors,
+		users:                users,
+		enrichers:            enrichers,
+		disabledEnrichers:    DisabledEnrichers,
+		breakerThreshold:     BreakerThreshold,
+		breakerCooldown:      BreakerCooldown,
+		queueSize:            QueueSize,
+		queuePolicy:          QueueFullPolicy,
+		syncTimeout:          SyncTimeout,
+		deadlineMargin:       DeadlineMargin,
+		batchSize:            BatchSize,
+		batchDelay:           BatchDelay,
+		dedupWindow:          DedupWindow,
+		crashDir:             crashDir,
+		hourlyQuota:          HourlyQuota,
+		routesEndpoint:       RoutesEndpoint,
+		routesInterval:       RoutesInterval,
+		clock:                systemClock{},
+		rand:                 globalRand{},
+		state:                stdState,
 	}
-	parts := strings.Split(f, RootPackage)
-	if len(parts) == 2 {
-		return "[PROJECT_ROOT]" + parts[1]
-	} else {
-		return f
+	if config, _ := loadedConfig.Load().(*Config); config != nil {
+		config.apply(n)
 	}
+	n.resolveKey()
+	return n
 }
 
-func post(params map[string]interface{}) error {
-	buffer := bytes.NewBufferString("")
-
-	if err := tmpl.Execute(buffer, params); err != nil {
-		log.Printf("Airbrake error: %s", err)
-		return err
-	}
-
-	if Verbose {
-		log.Printf("Airbrake payload for endpoint %s: %s", Endpoint, buffer)
-	}
-
-	response, err := http.Post(Endpoint, "text/xml", buffer)
-	if err != nil {
-		log.Printf("Airbrake error: %s", err)
-		return err
-	}
-
-	if Verbose {
-		body, _ := ioutil.ReadAll(response.Body)
-		log.Printf("response: %s", body)
-	}
-	response.Body.Close()
-
-	if Verbose {
-		log.Printf("Airbrake post: %s status code: %d", params["Error"], response.StatusCode)
-	}
+// AddFilter registers a filter for the notices sent by the package-level functions.
+// See Notifier.AddFilter.
+func AddFilter(filter func(*Notice) *Notice) {
+	filters = append(filters, filter)
+}
 
-	return nil
+// OnNotice registers an observer for the notices sent by the package-level
+// functions. See Notifier.OnNotice.
+func OnNotice(observer func(*Notice)) {
+	observers = append(observers, observer)
 }
 
 func Error(e error, request *http.Request) error {
-	if ApiKey == "" {
-		return apiKeyMissing
-	}
-
-	return post(params(e, request))
+	n := defaultNotifier()
+	return n.send(n.newNotice(e, request, 0))
 }
 
 func Notify(e error) error {
-	if ApiKey == "" {
-		return apiKeyMissing
-	}
+	n := defaultNotifier()
+	return n.send(n.newNotice(e, nil, 0))
+}
 
-	return post(params(e, nil))
+// NotifySkip works like Notify, but omits skip additional frames from the top
+// of the backtrace. Helpers wrapping Notify should pass 1 so that the backtrace
+// starts at their caller instead of at the helper itself.
+func NotifySkip(e error, skip int) error {
+	n := defaultNotifier()
+	return n.send(n.newNotice(e, nil, skip))
 }
 
-func params(e error, request *http.Request) map[string]interface{} {
-	params := map[string]interface{}{
-		"Class":       reflect.TypeOf(e).String(),
-		"Error":       e,
-		"ApiKey":      ApiKey,
-		"ErrorName":   e.Error(),
-		"Environment": Environment,
-	}
+// NotifyWithStack works like Notify, but reports the backtrace of the program
+// counters, as returned by runtime.Callers, e.g. captured where the error
+// was created rather than where it is reported.
+func NotifyWithStack(e error, pcs []uintptr) error {
+	return defaultNotifier().NotifyWithStack(e, pcs)
+}
 
-	if params["Class"] == "" {
-		params["Class"] = "Panic"
-	}
+// Notifyf reports an error built from the format and args, like fmt.Errorf.
+func Notifyf(format string, args ...interface{}) error {
+	n := defaultNotifier()
+	return n.send(n.newNotice(fmt.Errorf(format, args...), nil, 0))
+}
 
-	pwd, err := os.Getwd()
-	if err == nil {
-		params["Pwd"] = pwd
-	}
+// NotifyWithFields reports the error along with the given fields,
+// which are rendered on the Parameters tab (in Errbit).
+func NotifyWithFields(e error, fields map[string]interface{}) error {
+	n := defaultNotifier()
+	notice := n.newNotice(e, nil, 0)
+	addFields(notice, fields)
+	return n.send(notice)
+}
 
-	hostname, err := os.Hostname()
-	if err == nil {
-		params["Hostname"] = hostname
+// NotifyMessage reports an anomalous condition that has no error value,
+// using the explicit class and message. The fields are handled as
+// in NotifyWithFields.
+func NotifyMessage(class, message string, fields map[string]interface{}) error {
+	n := defaultNotifier()
+	notice := n.newNotice(errors.New(message), nil, 0)
+	notice.Error.Class = class
+	addFields(notice, fields)
+	return n.send(notice)
+}
+
+// addFields adds the fields to the request parameters, skipping sensitive keys.
+func addFields(n *Notice, fields map[string]interface{}) {
+	if len(fields) == 0 {
+		return
+	}
+	form := n.request().Params
+	for k, v := range fields {
+		value := fmt.Sprint(v)
+		if !omit(k, []string{value}) {
+			form[k] = value
+		}
 	}
+}
 
-	params["Backtrace"] = stacktrace(3)
+// addMemStats adds a snapshot of the memory allocator statistics to the params.
+func addMemStats(n *Notice) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
 
-	if request == nil || request.ParseForm() != nil {
-		return params
-	}
+	params := n.request().Params
+	params["memstats.HeapAlloc"] = strconv.FormatUint(m.HeapAlloc, 10)
+	params["memstats.Sys"] = strconv.FormatUint(m.Sys, 10)
+	params["memstats.NumGC"] = strconv.FormatUint(uint64(m.NumGC), 10)
+	params["memstats.LastPause"] = time.Duration(m.PauseNs[(m.NumGC+255)%256]).String()
+}
 
-	// Compile relevant request parameters into a map.
-	req := make(map[string]interface{})
-	params["Request"] = req
-	req["Component"] = ""
-	req["Action"] = ""
-	// Nested http Muxes muck with the URL, prefer RequestURI.
-	if request.RequestURI != "" {
-		req["URL"] = request.RequestURI
-	} else {
-		req["URL"] = request.URL
+// clientIP returns the address of the client that made the request.
+// X-Forwarded-For entries are only trusted when the request comes through
+// a trusted proxy, and trusted proxies are skipped.
+func clientIP(request *http.Request, trusted func(string) bool) string {
+	ip := remoteIP(request)
+	if !trusted(ip) {
+		return ip
 	}
 
-	// Compile header parameters.
-	header := make(map[string]string)
-	req["Header"] = header
-	header["REQUEST_METHOD"] = request.Method
-	header["REQUEST_PROTOCOL"] = request.Proto
-	for k, v := range request.Header {
-		if !omit(k, v) {
-			// errbit processes some entries, e.g. user agent, and expects
-			// the keys to be uppercased, underscored and prefixed with HTTP_
-			k := strings.ToUpper(strings.Replace(k, "-", "_", -1))
-			header["HTTP_"+k] = v[0]
+	forwarded := strings.Split(strings.Join(request.Header["X-Forwarded-For"], ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if addr == "" {
+			continue
 		}
-	}
-	// This allows errbit to hyperlink to specific commit in the app repo.
-	if AppVersion != "" {
-		header["APP_VERSION"] = AppVersion
-	}
-
-	// Compile query/form parameters.
-	form := make(map[string]string)
-	req["Form"] = form
-	for k, v := range request.Form {
-		if !omit(k, v) {
-			form[k] = v[0]
-			if PrettyParams {
-				header["?"+k] = v[0]
-			}
+		ip = addr
+		if !trusted(addr) {
+			break
 		}
 	}
+	return ip
+}
 
-	return params
+// internal checks if the address belongs to a loopback or private network.
+func internal(addr string) bool {
+	ip := net.ParseIP(addr)
+	return ip != nil && (ip.IsLoopback() || ip.IsPrivate())
 }
 
 // omit checks the key, values for emptiness or sensitivity.
@@ -233,46 +584,19 @@ func omit(key string, values []string) bool {
 
 func CapturePanic(r *http.Request) {
 	if rec := recover(); rec != nil {
+		n := defaultNotifier()
+		panic(n.reported(rec, n.capture(rec, r)))
+	}
+}
 
-		if err, ok := rec.(error); ok {
-			log.Printf("Recording err %s", err)
-			Error(err, r)
-		} else if err, ok := rec.(string); ok {
-			log.Printf("Recording string %s", err)
-			Error(errors.New(err), r)
+// goroutines returns the stacks of all running goroutines.
+func goroutines() string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 1<<24 {
+			return string(buf[:n])
 		}
-
-		panic(rec)
+		buf = make([]byte, 2*len(buf))
 	}
 }
-
-const source = `<?xml version="1.0" encoding="UTF-8"?>
-<notice version="2.0">
-  <api-key>{{ .ApiKey }}</api-key>
-  <notifier>
-    <name>Airbrake Golang</name>
-    <version>0.0.1</version>
-    <url>http://airbrake.io</url>
-  </notifier>
-  <error>
-    <class>{{ html .Class }}</class>
-    <message>{{ html .ErrorName }}</message>
-    <backtrace>{{ range .Backtrace }}
-      <line method="{{ html .Function}}" file="{{ html .File}}" number="{{.Line}}"/>{{ end }}
-    </backtrace>
-  </error>{{ with .Request }}
-  <request>
-    <url>{{html .URL}}</url>
-    <component>{{ .Component }}</component>
-    <action>{{ .Action }}</action>
-    <params>{{ range $key, $value := .Form }}
-      <var key="{{ $key }}">{{ $value }}</var>{{ end }}</params>
-    <cgi-data>{{ range $key, $value := .Header }}
-      <var key="{{ $key }}">{{ $value }}</var>{{ end }}</cgi-data>
-  </request>{{ end }}
-  <server-environment>
-    <project-root>{{ html .Pwd }}</project-root>
-    <environment-name>{{ .Environment }}</environment-name>
-    <hostname>{{ html .Hostname }}</hostname>
-  </server-environment>
-</notice>`
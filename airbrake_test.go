@@ -2,9 +2,13 @@ package airbrake
 
 import (
 	"bytes"
+	"encoding/xml"
 	"errors"
 	"net/http"
+	"os"
+	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 )
@@ -85,7 +89,7 @@ func TestLocate(t *testing.T) {
 			"/usr/local/go/src/pkg/net/http/server.go",
 		},
 	} {
-		if result := locate(sample.in); result != sample.out {
+		if result := defaultNotifier().locate(sample.in); result != sample.out {
 			t.Errorf("expected: %s got: %s", sample.out, result)
 		}
 	}
@@ -93,7 +97,7 @@ func TestLocate(t *testing.T) {
 
 // Make sure we match https://help.airbrake.io/kb/api-2/notifier-api-version-23
 func TestTemplateV2(t *testing.T) {
-	var p map[string]interface{}
+	var n *Notice
 	request, _ := http.NewRequest("GET", "/query?t=xxx&q=SHOW+x+BY+y+FROM+z&kEy=sesame&timezone=", nil)
 	request.Header.Set("Host", "Zulu")
 	request.Header.Set("Keep_Secret", "Sesame")
@@ -104,33 +108,33 @@ func TestTemplateV2(t *testing.T) {
 	func() {
 		defer func() {
 			if r := recover(); r != nil {
-				p = params(r.(error), request)
+				n = defaultNotifier().newNotice(r.(error), request, 0)
 			}
 		}()
 		panic(errors.New("Boom!"))
 	}()
 
 	// Did that work?
-	if p == nil {
+	if n == nil {
 		t.Fail()
 	}
 
 	// Crude backtrace check.
-	if len(p["Backtrace"].([]Line)) < 3 {
+	if len(n.Error.Backtrace) < 3 {
 		t.Fail()
 	}
 
 	// It's messy to generically test rendered backtrace, drop it.
-	delete(p, "Backtrace")
+	n.Error.Backtrace = nil
 
-	// Render the params.
-	var b bytes.Buffer
-	if err := tmpl.Execute(&b, p); err != nil {
-		t.Errorf("Template error: %s", err)
+	// Render the notice.
+	b, err := n.marshal()
+	if err != nil {
+		t.Errorf("Marshal error: %s", err)
 	}
 
 	// Validate the <error> node.
-	chunk := regexp.MustCompile(`(?s)<error>.*<backtrace>`).FindString(b.String())
+	chunk := regexp.MustCompile(`(?s)<error>.*<backtrace>`).FindString(string(b))
 	if chunk != `<error>
     <class>*errors.errorString</class>
     <message>Boom!</message>
@@ -139,20 +143,22 @@ func TestTemplateV2(t *testing.T) {
 	}
 
 	// Validate the <request> node.
-	chunk = regexp.MustCompile(`(?s)<request>.*</request>`).FindString(b.String())
+	chunk = regexp.MustCompile(`(?s)<request>.*</request>`).FindString(string(b))
 	if chunk != `<request>
     <url>/query?t=xxx&amp;q=SHOW+x+BY+y+FROM+z&amp;kEy=sesame&amp;timezone=</url>
     <component></component>
     <action></action>
     <params>
       <var key="q">SHOW x BY y FROM z</var>
-      <var key="t">xxx</var></params>
+      <var key="t">xxx</var>
+    </params>
     <cgi-data>
       <var key="?q">SHOW x BY y FROM z</var>
       <var key="?t">xxx</var>
       <var key="HTTP_HOST">Zulu</var>
       <var key="REQUEST_METHOD">GET</var>
-      <var key="REQUEST_PROTOCOL">HTTP/1.1</var></cgi-data>
+      <var key="REQUEST_PROTOCOL">HTTP/1.1</var>
+    </cgi-data>
   </request>` {
 		t.Error(chunk)
 	}
@@ -161,25 +167,25 @@ func TestTemplateV2(t *testing.T) {
 // Make sure we match https://help.airbrake.io/kb/api-2/notifier-api-version-23
 func TestEmptyParams(t *testing.T) {
 	request, _ := http.NewRequest("GET", "/query", nil)
-	var p map[string]interface{}
+	var n *Notice
 	// Trigger and recover a panic, so that we have something to render.
 	func() {
 		defer func() {
 			if r := recover(); r != nil {
-				p = params(r.(error), request)
+				n = defaultNotifier().newNotice(r.(error), request, 0)
 			}
 		}()
 		panic(errors.New("Boom!"))
 	}()
 
 	// Render the error.
-	var b bytes.Buffer
-	if err := tmpl.Execute(&b, p); err != nil {
-		t.Errorf("Template error: %s", err)
+	b, err := n.marshal()
+	if err != nil {
+		t.Errorf("Marshal error: %s", err)
 	}
 
 	// Validate the <request> node.
-	chunk := regexp.MustCompile(`(?s)<request>.*</request>`).FindString(b.String())
+	chunk := regexp.MustCompile(`(?s)<request>.*</request>`).FindString(string(b))
 	if chunk != `<request>
     <url>/query</url>
     <component></component>
@@ -187,8 +193,160 @@ func TestEmptyParams(t *testing.T) {
     <params></params>
     <cgi-data>
       <var key="REQUEST_METHOD">GET</var>
-      <var key="REQUEST_PROTOCOL">HTTP/1.1</var></cgi-data>
+      <var key="REQUEST_PROTOCOL">HTTP/1.1</var>
+    </cgi-data>
   </request>` {
 		t.Error(chunk)
 	}
 }
+
+// entry stands in for an exported entry point such as Notify.
+func entry(skip int) []Line {
+	return defaultNotifier().newNotice(errors.New("Wrapped"), nil, skip).Error.Backtrace
+}
+
+func report(skip int) []Line {
+	return entry(skip)
+}
+
+func TestNotifySkip(t *testing.T) {
+	if lines := report(0); lines[0].Function != "airbrake-go.report" {
+		t.Errorf("expected top frame in wrapper, got: %s", lines[0].Function)
+	}
+	if lines := report(1); lines[0].Function != "airbrake-go.TestNotifySkip" {
+		t.Errorf("expected top frame in caller, got: %s", lines[0].Function)
+	}
+}
+
+func TestGoroutines(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() { <-done }()
+
+	if dump := goroutines(); strings.Count(dump, "goroutine ") < 2 {
+		t.Errorf("expected all goroutines, got: %s", dump)
+	}
+}
+
+func TestTrimPanic(t *testing.T) {
+	var lines []Line
+	func() {
+		defer func() {
+			recover()
+			lines = trimPanic(stacktrace(1, false))
+		}()
+		panic(errors.New("Boom!"))
+	}()
+
+	if len(lines) == 0 || lines[0].Function != "airbrake-go.TestTrimPanic.func1" {
+		t.Errorf("expected panicking frame on top, got: %v", lines)
+	}
+}
+
+func TestAddFields(t *testing.T) {
+	n := defaultNotifier().newNotice(errors.New("Boom!"), nil, 0)
+	addFields(n, map[string]interface{}{"user": 42, "api_token": "xyz", "empty": ""})
+
+	form := n.Request.Params
+	if len(form) != 1 || form["user"] != "42" {
+		t.Errorf("unexpected fields: %v", form)
+	}
+}
+
+func TestEscaping(t *testing.T) {
+	request, _ := http.NewRequest("GET", "/query?a=%3Cb%3E%26%22&%3Cc%3E=1", nil)
+	request.Header.Set("X-Evil", `"><script>`)
+	n := defaultNotifier().newNotice(errors.New("<Boom & Bust>"), request, 0)
+	n.Request.Component = "<component>"
+
+	b, err := n.marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+	var decoded Notice
+	if err := xml.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("malformed payload: %s\n%s", err, b)
+	}
+	if again, _ := decoded.marshal(); !bytes.Equal(again, b) {
+		t.Errorf("round trip mismatch:\n%s\n%s", again, b)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	for _, sample := range []struct{ remote, forwarded, out string }{
+		{"203.0.113.9:1234", "", "203.0.113.9"},
+		{"203.0.113.9:1234", "198.51.100.1", "203.0.113.9"},
+		{"10.0.0.2:1234", "198.51.100.1, 10.0.0.1", "198.51.100.1"},
+		{"10.0.0.2:1234", "192.0.2.7, 198.51.100.1", "198.51.100.1"},
+		{"127.0.0.1:1234", "", "127.0.0.1"},
+	} {
+		request, _ := http.NewRequest("GET", "/", nil)
+		request.RemoteAddr = sample.remote
+		if sample.forwarded != "" {
+			request.Header.Set("X-Forwarded-For", sample.forwarded)
+		}
+		if result := clientIP(request, internal); result != sample.out {
+			t.Errorf("expected: %s got: %s", sample.out, result)
+		}
+	}
+}
+
+func TestEnvironmentVariables(t *testing.T) {
+	os.Setenv("AIRBRAKE_TEST_REGION", "eu")
+	os.Setenv("AIRBRAKE_TEST_SECRET", "sesame")
+	EnvironmentVariables = []string{"AIRBRAKE_TEST_REGION", "AIRBRAKE_TEST_SECRET", "AIRBRAKE_TEST_UNSET"}
+	defer func() { EnvironmentVariables = nil }()
+
+	n := defaultNotifier().newNotice(errors.New("Boom!"), nil, 0)
+	if expected := (vars{"AIRBRAKE_TEST_REGION": "eu"}); !reflect.DeepEqual(n.Request.CGIData, expected) {
+		t.Errorf("unexpected environment: %v", n.Request.CGIData)
+	}
+}
+
+func TestMemStats(t *testing.T) {
+	CaptureMemStats = true
+	defer func() { CaptureMemStats = false }()
+
+	n := defaultNotifier().newNotice(errors.New("Boom!"), nil, 0)
+	for _, k := range []string{"memstats.HeapAlloc", "memstats.Sys", "memstats.NumGC", "memstats.LastPause"} {
+		if n.Request.Params[k] == "" {
+			t.Errorf("missing %s in %v", k, n.Request.Params)
+		}
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	request, _ := http.NewRequest("GET", "/", nil)
+	request.Header.Set("X-Request-Id", "abc-123")
+
+	n := defaultNotifier().newNotice(errors.New("Boom!"), request, 0)
+	if n.Request.Params["request_id"] != "abc-123" {
+		t.Errorf("unexpected params: %v", n.Request.Params)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	n := defaultNotifier().newNotice(errors.New("bad \xff frame\x00\x1b[0m\n"), nil, 0)
+	addFields(n, map[string]interface{}{"raw\x01": "\xfe\tvalue"})
+	n.sanitize()
+
+	if n.Error.Message != "bad � frame[0m\n" {
+		t.Errorf("unexpected message: %q", n.Error.Message)
+	}
+	if expected := (vars{"raw": "�\tvalue"}); !reflect.DeepEqual(n.Request.Params, expected) {
+		t.Errorf("unexpected params: %q", n.Request.Params)
+	}
+}
+
+func TestHeaderAllowlist(t *testing.T) {
+	request, _ := http.NewRequest("GET", "/", nil)
+	request.Header.Set("User-Agent", "curl")
+	request.Header.Set("Cookie", "session=1")
+	request.Header.Set("X-Api-Key", "sesame")
+
+	n := NewNotifier("key", WithHeaderAllowlist("user-agent", "X-Api-Key")).newNotice(errors.New("Boom!"), request, 0)
+	expected := vars{"REQUEST_METHOD": "GET", "REQUEST_PROTOCOL": "HTTP/1.1"}
+	if !reflect.DeepEqual(n.Request.CGIData, expected) || n.Client.UserAgent != "curl" {
+		t.Errorf("unexpected headers: %v %+v", n.Request.CGIData, n.Client)
+	}
+}
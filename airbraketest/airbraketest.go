@@ -0,0 +1,78 @@
+// Package airbraketest reports the failures and panics of tests to airbrake,
+// e.g. of long-running integration or soak test suites, so that flaky tests
+// get the same triage workflow as the errors of the application. Use an API
+// key of a dedicated project.
+//
+// Example:
+//
+//	func TestMain(m *testing.M) {
+//	    airbraketest.Notifier = airbrake.NewNotifier(os.Getenv("SOAK_AIRBRAKE_API_KEY"))
+//	    os.Exit(airbraketest.Main(m))
+//	}
+//
+//	func TestCheckout(t *testing.T) {
+//	    defer airbraketest.ReportFailures(t)
+//	    ...
+//	}
+package airbraketest
+
+import (
+	"fmt"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/tobi/airbrake-go"
+)
+
+// Notifier reports the failures. If nil, the package-level functions are used.
+var Notifier *airbrake.Notifier
+
+// FlushTimeout bounds how long Main waits for the queued notices.
+var FlushTimeout = 5 * time.Second
+
+// ReportFailures reports the test if it fails, as a TestFailure notice with
+// the name of the test as the test param. Deferred, it also reports the
+// panics of the test, as TestPanic notices with the stack as the stack param,
+// before resuming them, unless they were already reported.
+func ReportFailures(t testing.TB) {
+	if rec := recover(); rec != nil {
+		if _, ok := rec.(*airbrake.ReportedPanic); ok {
+			panic(rec)
+		}
+		notify("TestPanic", fmt.Sprintf("%s panicked: %v", t.Name(), rec), map[string]interface{}{
+			"test":  t.Name(),
+			"stack": string(debug.Stack()),
+		})
+		panic(rec)
+	}
+	t.Cleanup(func() {
+		if t.Failed() {
+			notify("TestFailure", t.Name()+" failed", map[string]interface{}{"test": t.Name()})
+		}
+	})
+}
+
+// Main runs the tests, reports a TestSuiteFailure notice if they fail, and
+// waits for the queued notices, for up to FlushTimeout. It returns the exit
+// code of the tests.
+func Main(m *testing.M) int {
+	code := m.Run()
+	if code != 0 {
+		notify("TestSuiteFailure", fmt.Sprintf("tests failed with exit code %d", code), nil)
+	}
+	if Notifier != nil {
+		Notifier.Flush(FlushTimeout)
+	} else {
+		airbrake.Flush(FlushTimeout)
+	}
+	return code
+}
+
+func notify(class, message string, fields map[string]interface{}) {
+	if Notifier != nil {
+		Notifier.NotifyMessage(class, message, fields)
+	} else {
+		airbrake.NotifyMessage(class, message, fields)
+	}
+}
@@ -0,0 +1,72 @@
+package airbraketest
+
+import (
+	"testing"
+
+	"github.com/tobi/airbrake-go"
+)
+
+// failingTest fails, and runs its cleanups when asked to.
+type failingTest struct {
+	testing.TB
+	cleanups []func()
+}
+
+func (t *failingTest) Name() string     { return "TestCheckout" }
+func (t *failingTest) Failed() bool     { return true }
+func (t *failingTest) Cleanup(f func()) { t.cleanups = append(t.cleanups, f) }
+
+func TestReportFailures(t *testing.T) {
+	var reported []*airbrake.Notice
+	Notifier = airbrake.NewNotifier("key", airbrake.WithDryRun(true), airbrake.WithFilter(func(notice *airbrake.Notice) *airbrake.Notice {
+		reported = append(reported, notice)
+		return notice
+	}))
+	defer func() { Notifier = nil }()
+
+	failing := new(failingTest)
+	ReportFailures(failing)
+	for _, cleanup := range failing.cleanups {
+		cleanup()
+	}
+
+	func() {
+		defer func() { recover() }()
+		defer ReportFailures(failing)
+		panic("Boom!")
+	}()
+
+	if len(reported) != 2 {
+		t.Fatalf("expected 2 notices, got %d", len(reported))
+	}
+	if e := reported[0].Error; e.Class != "TestFailure" || e.Message != "TestCheckout failed" {
+		t.Errorf("unexpected error: %+v", e)
+	}
+	if e := reported[1].Error; e.Class != "TestPanic" || e.Message != "TestCheckout panicked: Boom!" ||
+		reported[1].Request.Params["stack"] == "" {
+		t.Errorf("unexpected notice: %+v", reported[1])
+	}
+}
+
+// erroringTest records the errors of the test.
+type erroringTest struct {
+	testing.TB
+	errors []interface{}
+}
+
+func (t *erroringTest) Helper()                   {}
+func (t *erroringTest) Error(args ...interface{}) { t.errors = append(t.errors, args...) }
+
+func TestCheckSerializer(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(UpdateGolden, "1")
+	CheckSerializer(t, airbrake.JSON, dir)
+	t.Setenv(UpdateGolden, "")
+	CheckSerializer(t, airbrake.JSON, dir)
+
+	erroring := new(erroringTest)
+	CheckSerializer(erroring, airbrake.XML, dir)
+	if len(erroring.errors) != 1 {
+		t.Errorf("expected the payloads to differ, got: %v", erroring.errors)
+	}
+}
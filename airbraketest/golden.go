@@ -0,0 +1,29 @@
+package airbraketest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/tobi/airbrake-go"
+)
+
+// UpdateGolden is the environment variable that, when set, makes
+// CheckSerializer write the golden files instead of comparing them.
+const UpdateGolden = "AIRBRAKE_UPDATE_GOLDEN"
+
+// CheckSerializer fails the test if the payloads rendered by the serializer
+// differ from the golden files in dir, see airbrake.CheckGolden. Run the test
+// with AIRBRAKE_UPDATE_GOLDEN=1 to write the golden files, then check that
+// the collector accepts them.
+//
+// Example:
+//
+//	func TestSerializer(t *testing.T) {
+//	    airbraketest.CheckSerializer(t, mySerializer, "testdata/my-serializer")
+//	}
+func CheckSerializer(t testing.TB, s airbrake.Serializer, dir string) {
+	t.Helper()
+	if err := airbrake.CheckGolden(s, dir, os.Getenv(UpdateGolden) != ""); err != nil {
+		t.Error(err)
+	}
+}
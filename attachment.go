@@ -0,0 +1,79 @@
+package airbrake
+
+import (
+	"encoding/base64"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Default caps of the attachments, if not set in Limits.
+const (
+	attachmentSize  = 64 << 10
+	attachmentsSize = 256 << 10
+)
+
+type attachment struct {
+	name string
+	data []byte
+}
+
+// Attach adds a named blob to the notice, e.g. a goroutine dump or a
+// snapshot of the configuration, in a filter. It is sent as the
+// attachment.<name> param: as is if it is text, base64 encoded otherwise.
+// Attachments are capped by the AttachmentSize and AttachmentsSize limits.
+func (n *Notice) Attach(name string, data []byte) {
+	n.attachments = append(n.attachments, attachment{name, data})
+}
+
+// NotifyWithAttachments reports the error along with the named blobs.
+// See Notice.Attach.
+func NotifyWithAttachments(e error, attachments map[string][]byte) error {
+	n := defaultNotifier()
+	notice := n.newNotice(e, nil, 0)
+	for name, data := range attachments {
+		notice.Attach(name, data)
+	}
+	return n.send(notice)
+}
+
+// NotifyWithAttachments works like the package-level NotifyWithAttachments.
+func (n *Notifier) NotifyWithAttachments(e error, attachments map[string][]byte) error {
+	notice := n.newNotice(e, nil, 0)
+	for name, data := range attachments {
+		notice.Attach(name, data)
+	}
+	return n.send(notice)
+}
+
+// attach renders the attachments as params, cutting each one to the
+// attachment size, and omitting those beyond the total size.
+func (n *Notice) attach(l Limits) {
+	each, total := l.AttachmentSize, l.AttachmentsSize
+	if each <= 0 {
+		each = attachmentSize
+	}
+	if total <= 0 {
+		total = attachmentsSize
+	}
+
+	for _, a := range n.attachments {
+		data, cut := a.data, false
+		if len(data) > each {
+			data, cut = data[:each], true
+		}
+		if len(data) > total {
+			n.request().Params["attachment."+a.name] = "[omitted, " + strconv.Itoa(len(a.data)) + " bytes]"
+			continue
+		}
+		total -= len(data)
+
+		value := string(data)
+		if !utf8.Valid(data) || sanitize(value) != value {
+			value = "base64:" + base64.StdEncoding.EncodeToString(data)
+		}
+		if cut {
+			value += truncated
+		}
+		n.request().Params["attachment."+a.name] = value
+	}
+}
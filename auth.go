@@ -0,0 +1,139 @@
+package airbrake
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// User is the user affected by an error, shown by errbit.
+type User struct {
+	ID       string `xml:"id,omitempty" json:"id,omitempty"`
+	Name     string `xml:"name,omitempty" json:"name,omitempty"`
+	Email    string `xml:"email,omitempty" json:"email,omitempty"`
+	Username string `xml:"username,omitempty" json:"username,omitempty"`
+}
+
+// ClaimsFunc returns the claims, or session values, of the user of a request,
+// or nil if there is none. See ExtractUser.
+type ClaimsFunc func(r *http.Request) map[string]interface{}
+
+// userExtractor is a ClaimsFunc registered with ExtractUser.
+type userExtractor struct {
+	claims ClaimsFunc
+	fields []string
+}
+
+// ExtractUser registers a source of claims for the notices of the requests
+// of the package-level functions. See Notifier.ExtractUser.
+func ExtractUser(claims ClaimsFunc, fields ...string) {
+	users = append(users, userExtractor{claims, fields})
+}
+
+// ExtractUser registers a source of claims, e.g. BearerClaims, that is run on
+// every notice built from a request. The user of the notice is set from the
+// sub (or id, user_id), name, email and preferred_username (or username)
+// claims, and the claims listed in fields are added to its session.
+//
+// Example:
+//
+//	n.ExtractUser(airbrake.BearerClaims, "tenant", "scope")
+func (n *Notifier) ExtractUser(claims ClaimsFunc, fields ...string) {
+	n.users = append(n.users, userExtractor{claims, fields})
+}
+
+// extractUser runs the user extractors on the notice of the request.
+func (n *Notifier) extractUser(request *http.Request, notice *Notice) {
+	for _, extractor := range n.users {
+		claims := extractor.claims(request)
+		if len(claims) == 0 {
+			continue
+		}
+		user := User{
+			ID:       claim(claims, "sub", "id", "user_id"),
+			Name:     claim(claims, "name"),
+			Email:    claim(claims, "email"),
+			Username: claim(claims, "preferred_username", "username"),
+		}
+		if user != (User{}) {
+			notice.User = &user
+		}
+		r := notice.request()
+		if r.Session == nil {
+			r.Session = make(vars)
+		}
+		for _, field := range extractor.fields {
+			if value := claim(claims, field); value != "" {
+				r.Session[field] = value
+			}
+		}
+	}
+}
+
+// claim returns the first of the claims that is set.
+func claim(claims map[string]interface{}, names ...string) string {
+	for _, name := range names {
+		if value, ok := claims[name]; ok && value != nil {
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
+
+// BearerClaims returns the claims of the JWT in the Authorization header of
+// the request. The signature is not verified: the claims are only reported.
+func BearerClaims(r *http.Request) map[string]interface{} {
+	token := r.Header.Get("Authorization")
+	if len(token) < 7 || !strings.EqualFold(token[:7], "Bearer ") {
+		return nil
+	}
+	parts := strings.Split(token[7:], ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil
+	}
+	var claims map[string]interface{}
+	if json.Unmarshal(payload, &claims) != nil {
+		return nil
+	}
+	return claims
+}
+
+// ContextClaims returns a ClaimsFunc reading the claims stored in the context
+// of the request under the key, e.g. by a middleware caching the result of
+// an OAuth2 token introspection. The value must be a map[string]interface{}.
+func ContextClaims(key interface{}) ClaimsFunc {
+	return func(r *http.Request) map[string]interface{} {
+		claims, _ := r.Context().Value(key).(map[string]interface{})
+		return claims
+	}
+}
+
+// SessionClaims returns a ClaimsFunc reading the values of the session of the
+// request, as returned by values, e.g. for gorilla/sessions:
+//
+//	airbrake.SessionClaims(func(r *http.Request) map[interface{}]interface{} {
+//	    session, _ := store.Get(r, "session")
+//	    return session.Values
+//	})
+//
+// Only the values with string keys are used.
+func SessionClaims(values func(r *http.Request) map[interface{}]interface{}) ClaimsFunc {
+	return func(r *http.Request) map[string]interface{} {
+		var claims map[string]interface{}
+		for k, v := range values(r) {
+			if key, ok := k.(string); ok {
+				if claims == nil {
+					claims = make(map[string]interface{})
+				}
+				claims[key] = v
+			}
+		}
+		return claims
+	}
+}
@@ -0,0 +1,160 @@
+package airbrake
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+var (
+	// pcsPool recycles the buffers for runtime.Callers.
+	pcsPool = sync.Pool{New: func() interface{} {
+		pcs := make([]uintptr, 64)
+		return &pcs
+	}}
+
+	// self is the directory of this package's source files.
+	self = func() string {
+		_, file, _, _ := runtime.Caller(0)
+		return filepath.Dir(file)
+	}()
+
+	// goroot is the source directory of the standard library, found from a
+	// function known to be in it, e.g. /usr/local/go/src/. It is empty in
+	// -trimpath builds, whose paths are relative.
+	goroot = func() string {
+		fn := runtime.FuncForPC(reflect.ValueOf(strings.Repeat).Pointer())
+		if fn == nil {
+			return ""
+		}
+		file, _ := fn.FileLine(fn.Entry())
+		return strings.TrimSuffix(file, "strings/strings.go")
+	}()
+)
+
+// Frame is a frame of a backtrace captured with CaptureStack.
+type Frame struct {
+	File     string
+	Line     int
+	Function string // e.g. airbrake.(*Notifier).Notify
+	Package  string // the import path, e.g. github.com/tobi/airbrake-go
+	Receiver string // the receiver type of a method, e.g. *Notifier
+	Name     string // the function name, without package and receiver, e.g. Notify
+}
+
+// CaptureStack returns the backtrace of the calling goroutine. skip is the
+// number of frames to omit, with 0 being the caller of CaptureStack.
+func CaptureStack(skip int) []Frame {
+	pcs := make([]uintptr, 64)
+	for {
+		n := runtime.Callers(skip+2, pcs)
+		if n < len(pcs) {
+			return frames(pcs[:n])
+		}
+		pcs = make([]uintptr, 2*len(pcs))
+	}
+}
+
+// frames resolves the program counters returned by runtime.Callers.
+func frames(pcs []uintptr) []Frame {
+	var result []Frame
+	iter := runtime.CallersFrames(pcs)
+	for {
+		frame, more := iter.Next()
+		if frame.Function != "" || frame.File != "" {
+			l := newLine(frame.Function, frame.File, frame.Line)
+			result = append(result, Frame{l.File, l.Line, l.Function, l.Package, l.Receiver, l.Name})
+		}
+		if !more {
+			return result
+		}
+	}
+}
+
+// lines resolves the program counters as a backtrace for a notice.
+func lines(pcs []uintptr) []Line {
+	var lines []Line
+	for _, frame := range frames(pcs) {
+		lines = append(lines, Line{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+			Package:  frame.Package,
+			Receiver: frame.Receiver,
+			Name:     frame.Name,
+		})
+	}
+	return lines
+}
+
+// newLine describes a frame of the function, as named by the runtime.
+func newLine(function, file string, line int) Line {
+	receiver, name := splitFunction(function)
+	return Line{
+		Function: shorten(function),
+		File:     file,
+		Line:     line,
+		Package:  packagePath(function),
+		Receiver: receiver,
+		Name:     name,
+	}
+}
+
+// closure reports whether the element of a function name is that of a closure
+// or a wrapper, e.g. func1.
+func closure(element string) bool {
+	for _, prefix := range []string{"func", "gowrap", "deferwrap"} {
+		if strings.HasPrefix(element, prefix) {
+			element = element[len(prefix):]
+			break
+		}
+	}
+	return element != "" && strings.Trim(element, "0123456789") == ""
+}
+
+// splitFunction splits the function name, as reported by the runtime,
+// into the receiver, if it is a method, and the name, e.g.
+// github.com/tobi/airbrake-go.(*Notifier).Notify into *Notifier and Notify.
+// Closures are named after the enclosing function, e.g. Notify.func1.
+func splitFunction(function string) (receiver, name string) {
+	name = strings.TrimPrefix(function[len(packagePath(function)):], ".")
+	if strings.HasPrefix(name, "(") {
+		if end := strings.Index(name, ")."); end >= 0 {
+			return name[1:end], name[end+2:]
+		}
+	}
+	if dot := strings.IndexByte(name, '.'); dot >= 0 {
+		method := name[dot+1:]
+		if next := strings.IndexByte(method, '.'); next >= 0 {
+			method = method[:next]
+		}
+		if !closure(method) {
+			return name[:dot], name[dot+1:]
+		}
+	}
+	return "", name
+}
+
+// library reports whether the file belongs to this package, not counting
+// its tests.
+func library(file string) bool {
+	return filepath.Dir(file) == self && !strings.HasSuffix(file, "_test.go")
+}
+
+// stdlib reports whether the function, with its full package path, is part
+// of the standard library. The runtime is not, since trimPanic needs its
+// frames to find where a panic started.
+func stdlib(name, file string) bool {
+	if strings.HasPrefix(name, "runtime.") {
+		return false
+	}
+	if goroot != "" {
+		return strings.HasPrefix(file, goroot)
+	}
+	// Without paths to go by, standard library packages are the ones
+	// without a domain name, like net/http.
+	first := strings.SplitN(packagePath(name), "/", 2)[0]
+	return !strings.Contains(first, ".") && first != "main"
+}
@@ -0,0 +1,145 @@
+package airbrake
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestLibrary(t *testing.T) {
+	_, test, _, _ := runtime.Caller(0)
+	if library(test) {
+		t.Errorf("expected tests not to be library frames: %s", test)
+	}
+	if !library(filepath.Join(filepath.Dir(test), "notifier.go")) {
+		t.Errorf("expected notifier.go to be a library frame")
+	}
+}
+
+func TestStdlib(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"net/http.HandlerFunc.ServeHTTP":   true,
+		"sort.Slice":                       true,
+		"runtime.gopanic":                  false,
+		"main.main":                        false,
+		"github.com/tobi/airbrake-go.Fail": false,
+	} {
+		file := goroot + "file.go"
+		if !expected {
+			file = "/src/app/file.go"
+		}
+		if result := stdlib(name, file); result != expected {
+			t.Errorf("%s expected: %v got: %v", name, expected, result)
+		}
+	}
+}
+
+// sorted captures the backtrace from within sort.
+func sorted(collapse bool) (lines []Line) {
+	sort.Slice([]int{2, 1}, func(i, j int) bool {
+		if lines == nil {
+			lines = stacktrace(1, collapse)
+		}
+		return false
+	})
+	return
+}
+
+func TestCollapseStdlib(t *testing.T) {
+	count := func(lines []Line) (n int) {
+		for _, line := range lines {
+			if strings.HasPrefix(line.Function, "sort.") {
+				n++
+			}
+		}
+		return
+	}
+
+	if n := count(sorted(false)); n <= 2 {
+		t.Fatalf("expected several sort frames, got: %d", n)
+	}
+	if n := count(sorted(true)); n != 2 {
+		t.Errorf("expected the sort frames to collapse to two, got: %d", n)
+	}
+}
+
+func TestCaptureStack(t *testing.T) {
+	frames := CaptureStack(0)
+	if len(frames) == 0 {
+		t.Fatal("no frames")
+	}
+	top := frames[0]
+	if top.Function != "airbrake-go.TestCaptureStack" || top.Package != "github.com/tobi/airbrake-go" ||
+		filepath.Base(top.File) != "backtrace_test.go" || top.Line == 0 {
+		t.Errorf("unexpected top frame: %+v", top)
+	}
+}
+
+// created captures the stack like an error wrapping library would.
+func created() []uintptr {
+	pcs := make([]uintptr, 32)
+	return pcs[:runtime.Callers(1, pcs)]
+}
+
+func TestNotifyWithStack(t *testing.T) {
+	var backtrace []Line
+	n := NewNotifier("key", WithFilter(func(notice *Notice) *Notice {
+		backtrace = notice.Error.Backtrace
+		return nil
+	}))
+	n.NotifyWithStack(errors.New("Boom!"), created())
+
+	if len(backtrace) == 0 || backtrace[0].Function != "airbrake-go.created" {
+		t.Errorf("expected the captured stack, got: %v", backtrace)
+	}
+}
+
+// inlined is small enough to be inlined into its callers.
+func inlined() []Line {
+	return notInlined()
+}
+
+//go:noinline
+func notInlined() []Line {
+	return stacktrace(1, false)
+}
+
+func TestInlinedFrames(t *testing.T) {
+	lines := inlined()
+	if len(lines) < 3 {
+		t.Fatalf("unexpected backtrace: %v", lines)
+	}
+	for i, function := range []string{"airbrake-go.notInlined", "airbrake-go.inlined", "airbrake-go.TestInlinedFrames"} {
+		if lines[i].Function != function || lines[i].Package != "github.com/tobi/airbrake-go" {
+			t.Errorf("expected %s at %d, got: %+v", function, i, lines[i])
+		}
+	}
+}
+
+func BenchmarkStacktrace(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		stacktrace(0, false)
+	}
+}
+
+func TestSplitFunction(t *testing.T) {
+	for function, expected := range map[string][2]string{
+		"github.com/tobi/airbrake-go.(*Notifier).Notify":       {"*Notifier", "Notify"},
+		"github.com/tobi/airbrake-go.(*Notifier).Notify.func1": {"*Notifier", "Notify.func1"},
+		"github.com/shop/billing.Invoice.Total":                {"Invoice", "Total"},
+		"github.com/shop/billing.Charge":                       {"", "Charge"},
+		"github.com/shop/billing.Charge.func2":                 {"", "Charge.func2"},
+		"github.com/shop/billing.Charge.gowrap1":               {"", "Charge.gowrap1"},
+		"main.main":                                            {"", "main"},
+		"gopkg.in/yaml%2ev2.(*decoder).unmarshal":              {"*decoder", "unmarshal"},
+		"github.com/shop/cache.(*LRU[...]).Get":                {"*LRU[...]", "Get"},
+	} {
+		if receiver, name := splitFunction(function); receiver != expected[0] || name != expected[1] {
+			t.Errorf("%s expected: %v got: %s %s", function, expected, receiver, name)
+		}
+	}
+}
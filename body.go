@@ -0,0 +1,140 @@
+package airbrake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxBodySize bounds how much of a request body is read for the notice.
+const maxBodySize = 1 << 20
+
+// parseForm parses the query and body parameters, including the text fields
+// of multipart forms.
+func parseForm(request *http.Request) error {
+	if err := request.ParseForm(); err != nil {
+		return err
+	}
+	if request.MultipartForm == nil {
+		// Fails for non-multipart requests, which is fine.
+		request.ParseMultipartForm(maxBodySize)
+	}
+	return nil
+}
+
+// formValues flattens the values of a parameter, so repeated parameters are
+// not lost: tags=a&tags=b (or tags[]=a&tags[]=b) become tags[0] and tags[1].
+// Sensitive parameters and empty values are omitted.
+func formValues(key string, values []string) map[string]string {
+	if len(values) == 1 {
+		if omit(key, values) {
+			return nil
+		}
+		return map[string]string{key: values[0]}
+	}
+
+	params := make(map[string]string)
+	key = strings.TrimSuffix(key, "[]")
+	for i, value := range values {
+		k := key + "[" + strconv.Itoa(i) + "]"
+		if !omit(k, []string{value}) {
+			params[k] = value
+		}
+	}
+	return params
+}
+
+// fileParams summarizes the file parts of a parsed multipart form,
+// instead of including their contents. Several files of a field are
+// flattened like formValues.
+func fileParams(request *http.Request) map[string]string {
+	if request.MultipartForm == nil {
+		return nil
+	}
+	params := make(map[string]string)
+	for k, files := range request.MultipartForm.File {
+		summaries := make([]string, 0, len(files))
+		for _, file := range files {
+			if file.Filename == "" {
+				continue
+			}
+			summaries = append(summaries, fmt.Sprintf("%s (%s, %d bytes)", file.Filename, file.Header.Get("Content-Type"), file.Size))
+		}
+		for key, summary := range formValues(k, summaries) {
+			params[key] = summary
+		}
+	}
+	return params
+}
+
+// jsonParams decodes the top level fields of a JSON request body.
+// Nested objects and arrays are rendered as JSON, with sensitive keys removed.
+func jsonParams(request *http.Request) map[string]string {
+	if request.Body == nil {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(request.Body, maxBodySize))
+	request.Body = readCloser{io.MultiReader(bytes.NewReader(body), request.Body), request.Body}
+	if err != nil {
+		return nil
+	}
+
+	var fields map[string]interface{}
+	if json.Unmarshal(body, &fields) != nil {
+		return nil
+	}
+
+	params := make(map[string]string)
+	for k, v := range fields {
+		var value string
+		switch v := scrub(v).(type) {
+		case map[string]interface{}, []interface{}:
+			b, _ := json.Marshal(v)
+			value = string(b)
+		case nil:
+			continue
+		default:
+			value = fmt.Sprint(v)
+		}
+		if !omit(k, []string{value}) {
+			params[k] = value
+		}
+	}
+	return params
+}
+
+// scrub removes the sensitive keys from decoded JSON values.
+func scrub(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, nested := range v {
+			if sensitive.MatchString(k) {
+				delete(v, k)
+			} else {
+				v[k] = scrub(nested)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = scrub(v[i])
+		}
+	}
+	return v
+}
+
+// readCloser restores a partially consumed request body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,82 @@
+package airbrake
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestJSONBody(t *testing.T) {
+	ParseJSONBody = true
+	defer func() { ParseJSONBody = false }()
+
+	body := `{"name":"x&y","count":3,"password":"hunter2","user":{"id":1,"api_key":"k"},"none":null}`
+	request, _ := http.NewRequest("POST", "/items", bytes.NewBufferString(body))
+	request.Header.Set("Content-Type", "application/json; charset=utf-8")
+	n := defaultNotifier().newNotice(errors.New("Boom!"), request, 0)
+
+	expected := vars{"name": "x&y", "count": "3", "user": `{"id":1}`}
+	if !reflect.DeepEqual(n.Request.Params, expected) {
+		t.Errorf("unexpected params: %v", n.Request.Params)
+	}
+
+	// The handler must still be able to read the body.
+	if restored, _ := ioutil.ReadAll(request.Body); string(restored) != body {
+		t.Errorf("body not restored: %s", restored)
+	}
+}
+
+func TestMultipartForm(t *testing.T) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("title", "Report")
+	w.WriteField("secret", "sesame")
+	part, _ := w.CreateFormFile("upload", "data.csv")
+	part.Write([]byte("a,b,c\n"))
+	w.Close()
+
+	request, _ := http.NewRequest("POST", "/upload", &body)
+	request.Header.Set("Content-Type", w.FormDataContentType())
+	n := defaultNotifier().newNotice(errors.New("Boom!"), request, 0)
+
+	expected := vars{"title": "Report", "upload": "data.csv (application/octet-stream, 6 bytes)"}
+	if !reflect.DeepEqual(n.Request.Params, expected) {
+		t.Errorf("unexpected params: %v", n.Request.Params)
+	}
+}
+
+func TestMultipartFormFiles(t *testing.T) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, name := range []string{"a.csv", "b.csv"} {
+		part, _ := w.CreateFormFile("uploads", name)
+		part.Write([]byte("a,b\n"))
+	}
+	w.Close()
+
+	request, _ := http.NewRequest("POST", "/upload", &body)
+	request.Header.Set("Content-Type", w.FormDataContentType())
+	n := defaultNotifier().newNotice(errors.New("Boom!"), request, 0)
+
+	expected := vars{
+		"uploads[0]": "a.csv (application/octet-stream, 4 bytes)",
+		"uploads[1]": "b.csv (application/octet-stream, 4 bytes)",
+	}
+	if !reflect.DeepEqual(n.Request.Params, expected) {
+		t.Errorf("unexpected params: %v", n.Request.Params)
+	}
+}
+
+func TestFormValues(t *testing.T) {
+	request, _ := http.NewRequest("GET", "/?tags=a&tags=b&ids[]=1&ids[]=&q=x&token=a&token=b", nil)
+	n := defaultNotifier().newNotice(errors.New("Boom!"), request, 0)
+
+	expected := vars{"tags[0]": "a", "tags[1]": "b", "ids[0]": "1", "q": "x"}
+	if !reflect.DeepEqual(n.Request.Params, expected) {
+		t.Errorf("unexpected params: %v", n.Request.Params)
+	}
+}
@@ -0,0 +1,73 @@
+package airbrake
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of posting notices while the endpoint
+// is considered down, after too many consecutive delivery failures.
+var ErrCircuitOpen = errors.New("Airbrake circuit breaker is open")
+
+// breaker short-circuits deliveries after consecutive failures.
+// Once the cooldown has passed, a single probe is let through,
+// and its outcome decides whether the breaker closes again.
+type breaker struct {
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow reports whether a delivery may be attempted.
+func (b *breaker) allow(threshold int, cooldown time.Duration, now time.Time) bool {
+	if threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < threshold {
+		return true
+	}
+	if b.probing || now.Sub(b.openedAt) < cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// open reports whether deliveries are short-circuited, without letting
+// a probe through.
+func (b *breaker) open(threshold int, cooldown time.Duration, now time.Time) bool {
+	if threshold <= 0 {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.failures >= threshold && (b.probing || now.Sub(b.openedAt) < cooldown)
+}
+
+// record registers the outcome of a delivery.
+func (b *breaker) record(err error, threshold int, now time.Time) {
+	if threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= threshold {
+		b.openedAt = now
+	}
+}
@@ -0,0 +1,45 @@
+package airbrake
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	status := http.StatusInternalServerError
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	n := NewNotifier("key", WithEndpoint(server.URL), WithBreaker(2, 50*time.Millisecond))
+	for i := 0; i < 2; i++ {
+		if err := n.Notify(errors.New("Boom!")); err != badResponse {
+			t.Errorf("expected badResponse, got: %v", err)
+		}
+	}
+	if err := n.Notify(errors.New("Boom!")); err != ErrCircuitOpen {
+		t.Errorf("expected ErrCircuitOpen, got: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got: %d", requests)
+	}
+
+	// After the cooldown a probe goes through and closes the breaker.
+	time.Sleep(60 * time.Millisecond)
+	status = http.StatusOK
+	for i := 0; i < 2; i++ {
+		if err := n.Notify(errors.New("Boom!")); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if stats := n.Stats(); stats != (Stats{Sent: 2, Failed: 2, ShortCircuited: 1}) {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,79 @@
+package airbrake
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// ReportBuildInfo sends an info notice, of class AirbrakeBuildInfo, with the
+// version of the main module, its VCS revision, the Go version and the
+// versions of the dependencies as params, e.g.
+//
+//	main.version: v1.4.0
+//	vcs.revision: 5d76750
+//	dep.github.com/lib/pq: v1.10.9
+//
+// so that new error classes can be correlated with upgrades. It is meant to
+// be called at startup, and only sends the notice the first time. With deps,
+// only the dependencies whose module path starts with one of them are listed.
+func ReportBuildInfo(deps ...string) error {
+	return defaultNotifier().ReportBuildInfo(deps...)
+}
+
+// ReportBuildInfo works like the package-level ReportBuildInfo, sending the
+// notice once per Notifier.
+func (n *Notifier) ReportBuildInfo(deps ...string) error {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return errors.New("airbrake: no build info, the binary was not built with module support")
+	}
+	var err error
+	n.state.buildInfo.Do(func() {
+		err = n.send(n.buildInfoNotice(info, deps))
+	})
+	return err
+}
+
+func (n *Notifier) buildInfoNotice(info *debug.BuildInfo, deps []string) *Notice {
+	version := info.Main.Version
+	if version == "" {
+		version = "(devel)"
+	}
+	notice := n.newNotice(fmt.Errorf("Started %s %s (%s)", info.Main.Path, version, info.GoVersion), nil, 1)
+	notice.Error.Class = "AirbrakeBuildInfo"
+	notice.Severity = SeverityInfo
+
+	params := notice.request().Params
+	params["go.version"] = info.GoVersion
+	params["main.path"] = info.Main.Path
+	params["main.version"] = version
+	for _, setting := range info.Settings {
+		if strings.HasPrefix(setting.Key, "vcs.") {
+			params[setting.Key] = setting.Value
+		}
+	}
+	for _, dep := range info.Deps {
+		if !listed(dep.Path, deps) {
+			continue
+		}
+		version := dep.Version
+		if r := dep.Replace; r != nil {
+			version += " => " + strings.TrimSpace(r.Path+" "+r.Version)
+		}
+		params["dep."+dep.Path] = version
+	}
+	return notice
+}
+
+// listed reports whether the module path starts with one of the prefixes,
+// or there are none.
+func listed(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return len(prefixes) == 0
+}
@@ -0,0 +1,44 @@
+package airbrake
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestBuildInfoNotice(t *testing.T) {
+	info := &debug.BuildInfo{
+		GoVersion: "go1.21.0",
+		Main:      debug.Module{Path: "github.com/user/project", Version: "v1.4.0"},
+		Deps: []*debug.Module{
+			{Path: "github.com/lib/pq", Version: "v1.10.9"},
+			{Path: "golang.org/x/net", Version: "v0.17.0", Replace: &debug.Module{Path: "../net"}},
+		},
+		Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "5d76750"}, {Key: "-trimpath", Value: "true"}},
+	}
+
+	notice := NewNotifier("key").buildInfoNotice(info, nil)
+	params := notice.Request.Params
+	if notice.Error.Message != "Started github.com/user/project v1.4.0 (go1.21.0)" || notice.Severity != SeverityInfo {
+		t.Errorf("unexpected notice: %+v", notice.Error)
+	}
+	if len(params) != 6 || params["vcs.revision"] != "5d76750" || params["dep.golang.org/x/net"] != "v0.17.0 => ../net" {
+		t.Errorf("unexpected params: %v", params)
+	}
+
+	params = NewNotifier("key").buildInfoNotice(info, []string{"github.com/lib/"}).Request.Params
+	if _, ok := params["dep.golang.org/x/net"]; ok || params["dep.github.com/lib/pq"] != "v1.10.9" {
+		t.Errorf("unexpected params: %v", params)
+	}
+}
+
+func TestReportBuildInfo(t *testing.T) {
+	n := NewNotifier("key", WithDryRun(true))
+	for i := 0; i < 2; i++ {
+		if err := n.ReportBuildInfo(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := n.Stats(); stats.DryRun != 1 {
+		t.Errorf("expected a single notice: %+v", stats)
+	}
+}
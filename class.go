@@ -0,0 +1,47 @@
+package airbrake
+
+import "errors"
+
+// ClassFunc, if set, chooses the class reported for errors, to control how
+// they are grouped. Returning "" falls back to the default class.
+var ClassFunc func(e error) string
+
+// classer is implemented by errors that choose their own class.
+type classer interface {
+	AirbrakeClass() string
+}
+
+// className returns the class reported for the error: the one returned by
+// the ClassFunc hook, or by an AirbrakeClass method of an error in the chain,
+// or else its type.
+func (n *Notifier) className(e error) string {
+	if n.classFunc != nil {
+		if c := n.classFunc(e); c != "" {
+			return c
+		}
+	}
+	var c classer
+	if errors.As(e, &c) {
+		if name := c.AirbrakeClass(); name != "" {
+			return name
+		}
+	}
+	return class(e)
+}
+
+// classify sets the class of the notice for the error. The class chosen by
+// the application takes precedence over the gRPC status code.
+func (n *Notifier) classify(notice *Notice, e error) {
+	notice.Error.Class = class(e)
+	addGRPC(notice, e)
+	if c := n.className(e); c != class(e) {
+		notice.Error.Class = c
+	}
+}
+
+// nilError stands for the nil errors passed to the notifier, which would
+// otherwise panic, so that the buggy call site gets reported instead.
+type nilError struct{}
+
+func (nilError) Error() string         { return "a nil error was reported" }
+func (nilError) AirbrakeClass() string { return "NilErrorReported" }
@@ -0,0 +1,49 @@
+package airbrake
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type paymentError struct{ code string }
+
+func (e paymentError) Error() string         { return "payment failed: " + e.code }
+func (e paymentError) AirbrakeClass() string { return "PaymentError" }
+
+func TestClassName(t *testing.T) {
+	hook := func(e error) string {
+		if errors.Is(e, errNotFound) {
+			return "NotFound"
+		}
+		return ""
+	}
+	n := NewNotifier("key", WithClassFunc(hook))
+
+	for e, expected := range map[error]string{
+		paymentError{"declined"}:                        "PaymentError",
+		fmt.Errorf("charge: %w", paymentError{"fraud"}): "PaymentError",
+		fmt.Errorf("user: %w", errNotFound):             "NotFound",
+		errors.New("Boom!"):                             "*errors.errorString",
+	} {
+		if notice := n.newNotice(e, nil, 0); notice.Error.Class != expected {
+			t.Errorf("%v expected: %s got: %s", e, expected, notice.Error.Class)
+		}
+	}
+}
+
+var errNotFound = errors.New("not found")
+
+func TestNotifyNil(t *testing.T) {
+	var reported *Notice
+	n := NewNotifier("key", WithDryRun(true), WithFilter(func(notice *Notice) *Notice {
+		reported = notice
+		return notice
+	}))
+	if err := n.Notify(nil); err != nil {
+		t.Fatal(err)
+	}
+	if reported.Error.Class != "NilErrorReported" || len(reported.Error.Backtrace) == 0 {
+		t.Errorf("unexpected error: %+v", reported.Error)
+	}
+}
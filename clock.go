@@ -0,0 +1,31 @@
+package airbrake
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Clock tells the time. The notifier uses it for timestamps and for the
+// time-based features, like the circuit breaker cooldown, so tests can
+// control it.
+type Clock interface {
+	Now() time.Time
+}
+
+// Rand is a source of randomness, like *rand.Rand, for the notifier's
+// random decisions, so tests can control them. Note that *rand.Rand is not
+// safe for concurrent use.
+type Rand interface {
+	// Float64 returns a number in [0.0, 1.0).
+	Float64() float64
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// globalRand uses the top-level functions of math/rand, which are safe
+// for concurrent use.
+type globalRand struct{}
+
+func (globalRand) Float64() float64 { return rand.Float64() }
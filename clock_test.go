@@ -0,0 +1,36 @@
+package airbrake
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func TestClock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	clock := &fakeClock{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	n := NewNotifier("key", WithEndpoint(server.URL), WithClock(clock), WithBreaker(1, time.Minute))
+
+	if notice := n.newNotice(errors.New("Boom!"), nil, 0); !notice.Time.Equal(clock.now) {
+		t.Errorf("unexpected time: %s", notice.Time)
+	}
+
+	n.Notify(errors.New("Boom!"))
+	if err := n.Notify(errors.New("Boom!")); err != ErrCircuitOpen {
+		t.Errorf("expected ErrCircuitOpen, got: %v", err)
+	}
+	clock.now = clock.now.Add(time.Minute)
+	if err := n.Notify(errors.New("Boom!")); err != badResponse {
+		t.Errorf("expected a probe after the cooldown, got: %v", err)
+	}
+}
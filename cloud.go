@@ -0,0 +1,160 @@
+package airbrake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+)
+
+// cloudTimeout bounds how long the metadata endpoints are queried for.
+const cloudTimeout = time.Second
+
+// CloudMetadata is an enricher adding the metadata of the EC2, GCE or Azure
+// instance the process runs on to the CGI data, e.g.
+//
+//	CLOUD_PROVIDER:      aws
+//	CLOUD_INSTANCE_ID:   i-0123456789abcdef0
+//	CLOUD_ZONE:          eu-west-1a
+//	CLOUD_INSTANCE_TYPE: m5.large
+//
+// so that errors can be traced to instances and zones. The metadata endpoints
+// are queried once, for up to a second, by the first notice. It is opt-in:
+//
+//	airbrake.AddEnricher(airbrake.CloudMetadata)
+var CloudMetadata Enricher = &cloudEnricher{
+	aws:   "http://169.254.169.254",
+	gcp:   "http://metadata.google.internal",
+	azure: "http://169.254.169.254",
+}
+
+type cloudEnricher struct {
+	aws, gcp, azure string
+
+	once     sync.Once
+	metadata map[string]string
+}
+
+func (c *cloudEnricher) Enrich(notice *Notice) {
+	c.once.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cloudTimeout)
+		defer cancel()
+		c.metadata = c.detect(ctx)
+	})
+	if len(c.metadata) == 0 {
+		return
+	}
+	cgi := notice.request().CGIData
+	for k, v := range c.metadata {
+		cgi[k] = v
+	}
+}
+
+// detect queries the metadata endpoints of the providers concurrently, and
+// returns the metadata of the first one answering.
+func (c *cloudEnricher) detect(ctx context.Context) map[string]string {
+	providers := map[string]func(context.Context) (id, zone, instanceType string, err error){
+		"aws":   c.ec2,
+		"gcp":   c.gce,
+		"azure": c.azureVM,
+	}
+	found := make(chan map[string]string, len(providers))
+	for name, fetch := range providers {
+		go func(name string, fetch func(context.Context) (string, string, string, error)) {
+			id, zone, instanceType, err := fetch(ctx)
+			if err != nil || id == "" {
+				found <- nil
+				return
+			}
+			metadata := map[string]string{"CLOUD_PROVIDER": name, "CLOUD_INSTANCE_ID": id}
+			if zone != "" {
+				metadata["CLOUD_ZONE"] = zone
+			}
+			if instanceType != "" {
+				metadata["CLOUD_INSTANCE_TYPE"] = instanceType
+			}
+			found <- metadata
+		}(name, fetch)
+	}
+	for range providers {
+		if metadata := <-found; metadata != nil {
+			return metadata
+		}
+	}
+	return nil
+}
+
+// ec2 reads the instance identity document, with an IMDSv2 token.
+func (c *cloudEnricher) ec2(ctx context.Context) (string, string, string, error) {
+	token, err := metadataRequest(ctx, "PUT", c.aws+"/latest/api/token", "X-aws-ec2-metadata-token-ttl-seconds", "60")
+	if err != nil {
+		return "", "", "", err
+	}
+	var document struct {
+		InstanceID       string `json:"instanceId"`
+		AvailabilityZone string `json:"availabilityZone"`
+		InstanceType     string `json:"instanceType"`
+	}
+	err = metadataJSON(ctx, c.aws+"/latest/dynamic/instance-identity/document", "X-aws-ec2-metadata-token", string(token), &document)
+	return document.InstanceID, document.AvailabilityZone, document.InstanceType, err
+}
+
+// gce reads the instance metadata, whose zone and machine type are resource
+// paths, e.g. projects/123/zones/us-central1-a.
+func (c *cloudEnricher) gce(ctx context.Context) (string, string, string, error) {
+	var instance struct {
+		ID          json.Number `json:"id"`
+		Zone        string      `json:"zone"`
+		MachineType string      `json:"machineType"`
+	}
+	err := metadataJSON(ctx, c.gcp+"/computeMetadata/v1/instance/?recursive=true", "Metadata-Flavor", "Google", &instance)
+	return instance.ID.String(), path.Base(instance.Zone), path.Base(instance.MachineType), err
+}
+
+// azureVM reads the compute metadata of the instance.
+func (c *cloudEnricher) azureVM(ctx context.Context) (string, string, string, error) {
+	var compute struct {
+		VMID     string `json:"vmId"`
+		Location string `json:"location"`
+		Zone     string `json:"zone"`
+		VMSize   string `json:"vmSize"`
+	}
+	err := metadataJSON(ctx, c.azure+"/metadata/instance/compute?api-version=2021-02-01", "Metadata", "true", &compute)
+	zone := compute.Location
+	if compute.Zone != "" {
+		zone += "-" + compute.Zone
+	}
+	return compute.VMID, zone, compute.VMSize, err
+}
+
+func metadataJSON(ctx context.Context, url, header, value string, v interface{}) error {
+	body, err := metadataRequest(ctx, "GET", url, header, value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// metadataRequest queries a metadata endpoint, with the header it requires
+// to prove the request is not forwarded.
+func metadataRequest(ctx context.Context, method, url, header, value string) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set(header, value)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, response.Status)
+	}
+	return ioutil.ReadAll(io.LimitReader(response.Body, maxResponseSize))
+}
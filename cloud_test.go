@@ -0,0 +1,48 @@
+package airbrake
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCloudMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/latest/api/token" && r.Method == "PUT":
+			w.Write([]byte("token"))
+		case r.URL.Path == "/latest/dynamic/instance-identity/document" && r.Header.Get("X-aws-ec2-metadata-token") == "token":
+			w.Write([]byte(`{"instanceId": "i-0123456789abcdef0", "availabilityZone": "eu-west-1a", "instanceType": "m5.large"}`))
+		case r.URL.Path == "/computeMetadata/v1/instance/" && r.Header.Get("Metadata-Flavor") == "Google":
+			w.Write([]byte(`{"id": 4520031799277581759, "zone": "projects/123/zones/us-central1-a", "machineType": "projects/123/machineTypes/n1-standard-1"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	for enricher, expected := range map[*cloudEnricher]map[string]string{
+		{aws: server.URL}: {
+			"CLOUD_PROVIDER": "aws", "CLOUD_INSTANCE_ID": "i-0123456789abcdef0", "CLOUD_ZONE": "eu-west-1a", "CLOUD_INSTANCE_TYPE": "m5.large",
+		},
+		{gcp: server.URL}: {
+			"CLOUD_PROVIDER": "gcp", "CLOUD_INSTANCE_ID": "4520031799277581759", "CLOUD_ZONE": "us-central1-a", "CLOUD_INSTANCE_TYPE": "n1-standard-1",
+		},
+		{azure: server.URL}: {},
+	} {
+		notice := new(Notice)
+		enricher.Enrich(notice)
+		var cgi vars
+		if notice.Request != nil {
+			cgi = notice.Request.CGIData
+		}
+		if len(cgi) != len(expected) {
+			t.Errorf("expected: %v got: %v", expected, cgi)
+		}
+		for k, v := range expected {
+			if cgi[k] != v {
+				t.Errorf("expected: %v got: %v", expected, cgi)
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Command airbrake-notify sends a notice from shell scripts and cron jobs.
+//
+// Example:
+//
+//	airbrake-notify -class CronFailure -message "backup failed" < context.json
+//
+// The configuration is read from the AIRBRAKE_* environment variables
+// (see airbrake.ConfigureFromEnv) and can be overridden with flags.
+// A JSON object on stdin is included in the params of the notice.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/tobi/airbrake-go"
+)
+
+func main() {
+	if err := airbrake.ConfigureFromEnv(); err != nil {
+		fail(err)
+	}
+
+	class := flag.String("class", "Notice", "error class")
+	message := flag.String("message", "", "error message (required)")
+	flag.StringVar(&airbrake.ApiKey, "key", airbrake.ApiKey, "API key (AIRBRAKE_API_KEY)")
+	flag.StringVar(&airbrake.Endpoint, "endpoint", airbrake.Endpoint, "notifier API endpoint (AIRBRAKE_ENDPOINT)")
+	flag.StringVar(&airbrake.Environment, "environment", airbrake.Environment, "environment name (AIRBRAKE_ENVIRONMENT)")
+	flag.BoolVar(&airbrake.Verbose, "verbose", airbrake.Verbose, "log the payload and response (AIRBRAKE_VERBOSE)")
+	flag.Parse()
+
+	if *message == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	fields, err := readFields()
+	if err != nil {
+		fail(err)
+	}
+	if err := airbrake.NotifyMessage(*class, *message, fields); err != nil {
+		fail(err)
+	}
+}
+
+// readFields decodes the JSON object piped to stdin, if any.
+func readFields() (map[string]interface{}, error) {
+	if stat, err := os.Stdin.Stat(); err != nil || stat.Mode()&os.ModeCharDevice != 0 {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(os.Stdin)
+	if err != nil || len(body) == 0 {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("stdin: %s", err)
+	}
+	return fields, nil
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "airbrake-notify: %s\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,137 @@
+package airbrake
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// CollectMode is how the errors collected during a request are reported,
+// see CollectHandler.
+type CollectMode int
+
+const (
+	// Combined reports a single notice, for the first error, listing all of
+	// them in the params as errors.0, errors.1, ...
+	Combined CollectMode = iota
+
+	// Tagged reports a notice per error, with the ID of the request as the
+	// collection param, and the position of the error as collection_index.
+	Tagged
+)
+
+// collectorKey is the context key of the collector.
+type collectorKey struct{}
+
+// collector accumulates the notices of the errors of a request. Once it
+// ended, the errors are reported right away.
+type collector struct {
+	mu      sync.Mutex
+	request *http.Request
+	notices []*Notice
+	ended   bool
+}
+
+// end returns the notices collected, and stops collecting.
+func (c *collector) end() []*Notice {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ended = true
+	return c.notices
+}
+
+// CollectHandler "middleware".
+// Wraps the http handler so that the non-fatal errors reported with Collect
+// during a request are sent when it ends, as a single notice or as notices
+// tagged with the request, depending on the mode, instead of one by one.
+//
+// Example:
+//
+//	http.Handle("/", airbrake.CollectHandler(mux, airbrake.Combined))
+func CollectHandler(app http.Handler, mode CollectMode) http.Handler {
+	return collectHandler(defaultNotifier, app, mode)
+}
+
+// CollectHandler works like the package-level CollectHandler.
+func (n *Notifier) CollectHandler(app http.Handler, mode CollectMode) http.Handler {
+	return collectHandler(func() *Notifier { return n }, app, mode)
+}
+
+func collectHandler(notifier func() *Notifier, app http.Handler, mode CollectMode) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := new(collector)
+		c.request = r.WithContext(context.WithValue(r.Context(), collectorKey{}, c))
+		defer func() { notifier().sendCollected(c.end(), mode) }()
+		app.ServeHTTP(w, c.request)
+	})
+}
+
+// Collect adds the error to the errors of the request of the context, which
+// are sent when it ends, see CollectHandler. Outside of such a request, the
+// error is reported right away, like with NotifyContext.
+func Collect(ctx context.Context, e error) error {
+	n := defaultNotifier()
+	return n.collect(ctx, n.contextNotice(ctx, e, collected(ctx)))
+}
+
+// Collect works like the package-level Collect.
+func (n *Notifier) Collect(ctx context.Context, e error) error {
+	return n.collect(ctx, n.contextNotice(ctx, e, collected(ctx)))
+}
+
+// collected returns the request of the collector of the context, if any.
+func collected(ctx context.Context) *http.Request {
+	if c, ok := ctx.Value(collectorKey{}).(*collector); ok {
+		return c.request
+	}
+	return nil
+}
+
+func (n *Notifier) collect(ctx context.Context, notice *Notice) error {
+	c, ok := ctx.Value(collectorKey{}).(*collector)
+	if !ok {
+		return n.send(notice)
+	}
+	c.mu.Lock()
+	if c.ended {
+		c.mu.Unlock()
+		return n.send(notice)
+	}
+	c.notices = append(c.notices, notice)
+	c.mu.Unlock()
+	return nil
+}
+
+// sendCollected reports the notices collected during a request.
+func (n *Notifier) sendCollected(notices []*Notice, mode CollectMode) {
+	switch {
+	case len(notices) == 0:
+	case mode == Tagged:
+		id := collectionID()
+		for i, notice := range notices {
+			params := notice.request().Params
+			params["collection"] = id
+			params["collection_index"] = strconv.Itoa(i)
+			n.send(notice)
+		}
+	case len(notices) == 1:
+		n.send(notices[0])
+	default:
+		errs := make([]error, len(notices))
+		for i, notice := range notices {
+			errs[i] = notice.err
+		}
+		addErrors(notices[0], errs, n.className)
+		n.send(notices[0])
+	}
+}
+
+// collectionID returns a random ID for the notices of a request.
+func collectionID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
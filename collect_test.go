@@ -0,0 +1,47 @@
+package airbrake
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCollectHandler(t *testing.T) {
+	var reported []*Notice
+	n := NewNotifier("key", capturing(&reported))
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n.Collect(r.Context(), errors.New("cache miss"))
+		n.Collect(r.Context(), errNotFound)
+		if len(reported) != 0 {
+			t.Error("expected the errors to be collected")
+		}
+	})
+
+	n.CollectHandler(app, Combined).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/cart?id=1", nil))
+	if len(reported) != 1 {
+		t.Fatalf("expected a single notice, got %d", len(reported))
+	}
+	r := reported[0].Request
+	if reported[0].Error.Message != "cache miss" || r.URL != "/cart?id=1" ||
+		r.Params["errors.0"] != "*errors.errorString: cache miss" || r.Params["errors.1"] == "" {
+		t.Errorf("unexpected notice: %+v %+v", reported[0].Error, r)
+	}
+
+	reported = nil
+	n.CollectHandler(app, Tagged).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/cart", nil))
+	if len(reported) != 2 {
+		t.Fatalf("expected 2 notices, got %d", len(reported))
+	}
+	first, second := reported[0].Request.Params, reported[1].Request.Params
+	if first["collection"] == "" || first["collection"] != second["collection"] || second["collection_index"] != "1" {
+		t.Errorf("unexpected params: %v %v", first, second)
+	}
+
+	reported = nil
+	n.Collect(context.Background(), errNotFound)
+	if len(reported) != 1 {
+		t.Errorf("expected the error to be reported outside of a request")
+	}
+}
@@ -0,0 +1,62 @@
+package airbrake
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// ConfigureFromEnv sets the package configuration from the environment:
+//
+//	AIRBRAKE_API_KEY       ApiKey
+//	AIRBRAKE_ENDPOINT      Endpoint
+//	AIRBRAKE_ENVIRONMENT   Environment
+//	AIRBRAKE_ROOT_PACKAGE  RootPackage
+//	AIRBRAKE_APP_VERSION   AppVersion
+//	AIRBRAKE_USER_AGENT    UserAgent
+//	AIRBRAKE_VERBOSE       Verbose
+//	AIRBRAKE_PROJECT_ID    see below
+//
+// With AIRBRAKE_PROJECT_ID, and without AIRBRAKE_ENDPOINT, the notices are
+// posted to the notifier API version 3 endpoint of the project, i.e.
+// https://api.airbrake.io/api/v3/projects/<id>/notices, with the JSON
+// serializer, which sends the API key of each notice as a bearer token.
+// Unset variables leave the corresponding setting unchanged.
+func ConfigureFromEnv() error {
+	for name, setting := range map[string]*string{
+		"AIRBRAKE_API_KEY":      &ApiKey,
+		"AIRBRAKE_ENDPOINT":     &Endpoint,
+		"AIRBRAKE_ENVIRONMENT":  &Environment,
+		"AIRBRAKE_ROOT_PACKAGE": &RootPackage,
+		"AIRBRAKE_APP_VERSION":  &AppVersion,
+		"AIRBRAKE_USER_AGENT":   &UserAgent,
+	} {
+		if value, ok := os.LookupEnv(name); ok {
+			*setting = value
+		}
+	}
+
+	if value, ok := os.LookupEnv("AIRBRAKE_VERBOSE"); ok {
+		verbose, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("AIRBRAKE_VERBOSE: %s", err)
+		}
+		Verbose = verbose
+	}
+
+	if id, ok := os.LookupEnv("AIRBRAKE_PROJECT_ID"); ok {
+		if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+			return fmt.Errorf("AIRBRAKE_PROJECT_ID: %q is not a project ID", id)
+		}
+		if _, ok := os.LookupEnv("AIRBRAKE_ENDPOINT"); !ok {
+			Endpoint = projectEndpoint(id)
+			NoticeSerializer = JSON
+		}
+	}
+	return nil
+}
+
+// projectEndpoint returns the notifier API version 3 endpoint of the project.
+func projectEndpoint(id string) string {
+	return "https://api.airbrake.io/api/v3/projects/" + id + "/notices"
+}
@@ -0,0 +1,50 @@
+package airbrake
+
+import (
+	"os"
+	"testing"
+)
+
+func TestConfigureFromEnv(t *testing.T) {
+	defer func(apiKey, environment string) { ApiKey, Environment, Verbose = apiKey, environment, false }(ApiKey, Environment)
+	os.Setenv("AIRBRAKE_API_KEY", "abc")
+	os.Setenv("AIRBRAKE_ENVIRONMENT", "staging")
+	os.Setenv("AIRBRAKE_VERBOSE", "true")
+	defer os.Unsetenv("AIRBRAKE_API_KEY")
+	defer os.Unsetenv("AIRBRAKE_ENVIRONMENT")
+	defer os.Unsetenv("AIRBRAKE_VERBOSE")
+
+	if err := ConfigureFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if ApiKey != "abc" || Environment != "staging" || !Verbose {
+		t.Errorf("unexpected config: %s %s %v", ApiKey, Environment, Verbose)
+	}
+
+	os.Setenv("AIRBRAKE_VERBOSE", "loud")
+	if err := ConfigureFromEnv(); err == nil {
+		t.Error("expected error for malformed AIRBRAKE_VERBOSE")
+	}
+}
+
+func TestConfigureFromEnvProjectID(t *testing.T) {
+	defer func(apiKey, endpoint string, serializer Serializer) {
+		ApiKey, Endpoint, NoticeSerializer = apiKey, endpoint, serializer
+	}(ApiKey, Endpoint, NoticeSerializer)
+	os.Setenv("AIRBRAKE_API_KEY", "abc")
+	os.Setenv("AIRBRAKE_PROJECT_ID", "42")
+	defer os.Unsetenv("AIRBRAKE_API_KEY")
+	defer os.Unsetenv("AIRBRAKE_PROJECT_ID")
+
+	if err := ConfigureFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if Endpoint != "https://api.airbrake.io/api/v3/projects/42/notices" || NoticeSerializer != JSON {
+		t.Errorf("unexpected config: %s %v", Endpoint, NoticeSerializer)
+	}
+
+	os.Setenv("AIRBRAKE_PROJECT_ID", "my-project")
+	if err := ConfigureFromEnv(); err == nil {
+		t.Error("expected error for malformed AIRBRAKE_PROJECT_ID")
+	}
+}
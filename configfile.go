@@ -0,0 +1,222 @@
+package airbrake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config is the content of a JSON configuration file, e.g.
+//
+//	{
+//	    "api_key": "0123456789abcdef",
+//	    "environment": "production",
+//	    "scrub_keys": ["ssn", "iban"],
+//	    "ignore_classes": ["*errors.errorString"],
+//	    "sample_rate": 0.25
+//	}
+//
+// Omitted fields leave the corresponding setting unchanged. YAML is not
+// supported, as the package has no dependencies outside the standard library.
+type Config struct {
+	APIKey      string `json:"api_key"`
+	Endpoint    string `json:"endpoint"`
+	Environment string `json:"environment"`
+	AppVersion  string `json:"app_version"`
+	RootPackage string `json:"root_package"`
+	Verbose     *bool  `json:"verbose"`
+
+	// RootPackages works like the RootPackages setting.
+	RootPackages map[string]string `json:"root_packages"`
+
+	// Tags are added to the tags of the notifier, see the Tags setting.
+	Tags map[string]string `json:"tags"`
+
+	// ScrubKeys are removed from the params and CGI data of the notices,
+	// if they contain one of them, ignoring case.
+	ScrubKeys []string `json:"scrub_keys"`
+
+	// IgnoreClasses are the error classes of the notices to drop.
+	IgnoreClasses []string `json:"ignore_classes"`
+
+	// SampleRate is the fraction of the notices to send, between 0 and 1.
+	SampleRate *float64 `json:"sample_rate"`
+
+	// Serializer is the name of a registered serializer, e.g. "json/v3".
+	// See RegisterSerializer.
+	Serializer string `json:"serializer"`
+}
+
+// loadedConfig holds the *Config last loaded by LoadConfig, which the
+// package-level functions apply on top of the package-level settings.
+var loadedConfig atomic.Value
+
+// ReadConfig parses the configuration file as JSON.
+func ReadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := new(Config)
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("airbrake: %s: %w", path, err)
+	}
+	if r := config.SampleRate; r != nil && (*r < 0 || *r > 1) {
+		return nil, fmt.Errorf("airbrake: %s: sample_rate %g is not between 0 and 1", path, *r)
+	}
+	if name := config.Serializer; name != "" {
+		if _, ok := LookupSerializer(name); !ok {
+			return nil, fmt.Errorf("airbrake: %s: unknown serializer %q, registered: %s", path, name, strings.Join(Serializers(), ", "))
+		}
+	}
+	return config, nil
+}
+
+// LoadConfig reads the configuration file, and applies it to the package-level
+// functions, replacing the configuration previously loaded, if any. If the
+// file cannot be read or parsed, the current configuration is kept.
+func LoadConfig(path string) error {
+	config, err := ReadConfig(path)
+	if err != nil {
+		return err
+	}
+	loadedConfig.Store(config)
+	return nil
+}
+
+// WatchConfig loads the configuration file, then checks every interval whether
+// it was modified, and loads it again if so, so that the scrubbing or sampling
+// can be tuned without restarting. Invalid changes are logged and ignored.
+// The file is polled by its modification time, rather than watched with file
+// system notifications, so a change is seen within interval.
+// stop stops watching the file.
+func WatchConfig(path string, interval time.Duration) (stop func(), err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := LoadConfig(path); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		modified := info.ModTime()
+		for {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+			info, err := os.Stat(path)
+			if err != nil || info.ModTime().Equal(modified) {
+				continue
+			}
+			modified = info.ModTime()
+			if err := LoadConfig(path); err != nil {
+				log.Printf("Airbrake error: %s", err)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }, nil
+}
+
+// Options returns the options applying the configuration to a Notifier.
+func (c *Config) Options() []Option {
+	return []Option{func(n *Notifier) { c.apply(n) }}
+}
+
+func (c *Config) apply(n *Notifier) {
+	for _, setting := range []struct {
+		value  string
+		target *string
+	}{
+		{c.APIKey, &n.apiKey},
+		{c.Endpoint, &n.endpoint},
+		{c.Environment, &n.environment},
+		{c.AppVersion, &n.appVersion},
+		{c.RootPackage, &n.rootPackage},
+	} {
+		if setting.value != "" {
+			*setting.target = setting.value
+		}
+	}
+	if c.Verbose != nil {
+		n.verbose = *c.Verbose
+	}
+	if len(c.RootPackages) > 0 {
+		n.rootPackages = sortRoots(c.RootPackages)
+	}
+	if len(c.Tags) > 0 {
+		// Don't modify the map shared with other notifiers.
+		tags := make(map[string]string, len(n.tags)+len(c.Tags))
+		for k, v := range n.tags {
+			tags[k] = v
+		}
+		for k, v := range c.Tags {
+			tags[k] = v
+		}
+		n.tags = tags
+	}
+	if s, ok := LookupSerializer(c.Serializer); ok {
+		n.serializer = s
+	}
+	if len(c.ScrubKeys) > 0 || len(c.IgnoreClasses) > 0 || c.SampleRate != nil {
+		// Don't append to the slices shared with other notifiers.
+		n.configs = append(n.configs[:len(n.configs):len(n.configs)], c)
+		n.filters = append(n.filters[:len(n.filters):len(n.filters)], func(notice *Notice) *Notice {
+			return c.filter(notice, n.rand)
+		})
+	}
+}
+
+// filter drops the ignored and unsampled notices, and scrubs the others.
+func (c *Config) filter(notice *Notice, rand Rand) *Notice {
+	if c.ignores(notice.Error.Class) {
+		return nil
+	}
+	if c.SampleRate != nil && rand.Float64() >= *c.SampleRate {
+		return nil
+	}
+	if r := notice.Request; r != nil {
+		for _, v := range []vars{r.Params, r.Session, r.CGIData} {
+			for k := range v {
+				if c.scrubbed(k) {
+					delete(v, k)
+				}
+			}
+		}
+	}
+	return notice
+}
+
+// ignores reports whether the notices of the class are dropped, because the
+// class is ignored or the sample rate is 0.
+func (c *Config) ignores(class string) bool {
+	for _, ignored := range c.IgnoreClasses {
+		if class == ignored {
+			return true
+		}
+	}
+	return c.SampleRate != nil && *c.SampleRate == 0
+}
+
+func (c *Config) scrubbed(key string) bool {
+	key = strings.ToLower(key)
+	for _, scrub := range c.ScrubKeys {
+		if strings.Contains(key, strings.ToLower(scrub)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package airbrake
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type fixedRand float64
+
+func (r fixedRand) Float64() float64 { return float64(r) }
+
+func writeConfig(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "airbrake.json")
+	writeConfig(t, path, `{"environment": "production", "scrub_keys": ["SSN"], "ignore_classes": ["NotFound"], "sample_rate": 0.5}`)
+	config, err := ReadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var received []*Notice
+	options := append(config.Options(), WithFilter(func(notice *Notice) *Notice {
+		received = append(received, notice)
+		return nil
+	}))
+	sampled := NewNotifier("key", append([]Option{WithRand(fixedRand(0.25))}, options...)...)
+	unsampled := NewNotifier("key", append([]Option{WithRand(fixedRand(0.75))}, options...)...)
+	if sampled.environment != "production" || sampled.apiKey != "key" {
+		t.Errorf("unexpected config: %s %s", sampled.environment, sampled.apiKey)
+	}
+
+	sampled.NotifyWithFields(errors.New("Boom!"), map[string]interface{}{"customer_ssn": "078-05-1120", "plan": "pro"})
+	sampled.NotifyMessage("NotFound", "Boom!", nil)
+	unsampled.Notify(errors.New("Boom!"))
+	if len(received) != 1 {
+		t.Fatalf("expected 1 notice, got: %d", len(received))
+	}
+	if params := received[0].Request.Params; params["customer_ssn"] != "" || params["plan"] != "pro" {
+		t.Errorf("unexpected params: %v", params)
+	}
+
+	writeConfig(t, path, `{"sample_rate": 2}`)
+	if _, err := ReadConfig(path); err == nil {
+		t.Error("expected an error for the sample rate")
+	}
+
+	writeConfig(t, path, `{"serializer": "json/v3"}`)
+	if config, err := ReadConfig(path); err != nil || NewNotifier("key", config.Options()...).serializer != JSON {
+		t.Errorf("expected the JSON serializer, got: %v", err)
+	}
+	writeConfig(t, path, `{"serializer": "json"}`)
+	if _, err := ReadConfig(path); err == nil {
+		t.Error("expected an error for the serializer")
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	defer loadedConfig.Store((*Config)(nil))
+	path := filepath.Join(t.TempDir(), "airbrake.json")
+	writeConfig(t, path, `{"environment": "staging"}`)
+
+	stop, err := WatchConfig(path, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	if n := defaultNotifier(); n.environment != "staging" {
+		t.Errorf("unexpected environment: %s", n.environment)
+	}
+
+	writeConfig(t, path, `{"environment": "production"`)
+	writeConfig(t, path, `{"environment": "production"}`)
+	modified := time.Now().Add(time.Hour)
+	os.Chtimes(path, modified, modified)
+	deadline := time.Now().Add(time.Second)
+	for defaultNotifier().environment != "production" {
+		if time.Now().After(deadline) {
+			t.Fatal("the configuration was not reloaded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
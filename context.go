@@ -0,0 +1,79 @@
+package airbrake
+
+import (
+	"context"
+	"net/http"
+	"runtime/pprof"
+)
+
+// ExtractFromContext registers a function that is run on every notice of the
+// package-level functions built from a request or a context.
+// See Notifier.ExtractFromContext.
+func ExtractFromContext(extract func(ctx context.Context, n *Notice)) {
+	extractors = append(extractors, extract)
+}
+
+// ExtractFromContext registers a function that is run on every notice built
+// from a request, with the context of the request, or by NotifyContext and
+// ErrorContext, with their context. It adds the values that applications keep
+// in their contexts, like the user, tenant or locale, without touching every
+// call site.
+//
+// Example:
+//
+//	n.ExtractFromContext(func(ctx context.Context, notice *airbrake.Notice) {
+//	    if user, ok := ctx.Value(userKey{}).(*User); ok {
+//	        notice.Request.Params["user_id"] = user.ID
+//	    }
+//	})
+//
+// Notice.Request is always set when the function runs.
+func (n *Notifier) ExtractFromContext(extract func(ctx context.Context, n *Notice)) {
+	n.extractors = append(n.extractors, extract)
+}
+
+// NotifyContext works like Notify, but runs the functions registered with
+// ExtractFromContext, and attaches the trace and span IDs, of the context.
+func NotifyContext(ctx context.Context, e error) error {
+	n := defaultNotifier()
+	return n.send(n.contextNotice(ctx, e, nil))
+}
+
+// NotifyContext works like the package-level NotifyContext.
+func (n *Notifier) NotifyContext(ctx context.Context, e error) error {
+	return n.send(n.contextNotice(ctx, e, nil))
+}
+
+// contextNotice compiles the notice for an error of the exported entry point
+// taking the context. The context of the request has already been extracted,
+// if it is the same.
+func (n *Notifier) contextNotice(ctx context.Context, e error, request *http.Request) *Notice {
+	notice := n.newNotice(e, request, 1)
+	n.addTrace(ctx, notice, request)
+	if ctx != nil {
+		notice.deadline, _ = ctx.Deadline()
+	}
+	if ctx != nil && (request == nil || ctx != request.Context()) {
+		n.addDeadline(ctx, notice)
+		n.extract(ctx, notice)
+	}
+	return notice
+}
+
+// extract adds the profiler labels of the context to the notice, and runs the
+// context extractors on it.
+func (n *Notifier) extract(ctx context.Context, notice *Notice) {
+	if n.captureLabels {
+		pprof.ForLabels(ctx, func(key, value string) bool {
+			notice.request().Params["label."+key] = value
+			return true
+		})
+	}
+	if len(n.extractors) == 0 {
+		return
+	}
+	notice.request()
+	for _, extract := range n.extractors {
+		extract(ctx, notice)
+	}
+}
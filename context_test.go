@@ -0,0 +1,51 @@
+package airbrake
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+)
+
+type tenantKey struct{}
+
+func TestExtractFromContext(t *testing.T) {
+	n := NewNotifier("key")
+	n.ExtractFromContext(func(ctx context.Context, notice *Notice) {
+		if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+			notice.Request.Params["tenant"] = tenant
+		}
+	})
+
+	request := httptest.NewRequest("GET", "/invoices", nil)
+	request = request.WithContext(context.WithValue(request.Context(), tenantKey{}, "acme"))
+	if notice := n.newNotice(errors.New("Boom!"), request, 0); notice.Request.Params["tenant"] != "acme" {
+		t.Errorf("unexpected params: %v", notice.Request.Params)
+	}
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "globex")
+	if notice := n.contextNotice(ctx, errors.New("Boom!"), nil); notice.Request.Params["tenant"] != "globex" {
+		t.Errorf("unexpected params: %v", notice.Request.Params)
+	}
+	if notice := n.contextNotice(ctx, errors.New("Boom!"), request); notice.Request.Params["tenant"] != "globex" {
+		t.Errorf("expected the explicit context to win, got: %v", notice.Request.Params)
+	}
+	if notice := n.newNotice(errors.New("Boom!"), nil, 0); notice.Request != nil {
+		t.Errorf("unexpected request: %+v", notice.Request)
+	}
+}
+
+func TestCaptureLabels(t *testing.T) {
+	var reported *Notice
+	n := NewNotifier("key", WithDryRun(true), WithLabels(true), WithFilter(func(notice *Notice) *Notice {
+		reported = notice
+		return notice
+	}))
+	pprof.Do(context.Background(), pprof.Labels("worker", "billing"), func(ctx context.Context) {
+		n.NotifyContext(ctx, errors.New("Boom!"))
+	})
+	if reported.Request.Params["label.worker"] != "billing" {
+		t.Errorf("unexpected params: %v", reported.Request.Params)
+	}
+}
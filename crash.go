@@ -0,0 +1,80 @@
+package airbrake
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// crashDir is the directory set by MonitorCrash for the package-level functions.
+var crashDir string
+
+// MonitorCrash persists the notices of the panics, as reported by CapturePanic,
+// NotifyOnExit and the other panic handlers, to the directory before sending
+// them, and removes them once delivered. It then sends the notices left in
+// the directory by a previous process, which crashed or exited before they
+// could be delivered, e.g. because the endpoint was down. It is meant to be
+// called at startup, with a directory dedicated to the application instance.
+// The notices that still cannot be delivered are kept for the next start,
+// unless the endpoint rejected them for good.
+func MonitorCrash(dir string) error {
+	crashDir = dir
+	return defaultNotifier().resendCrashes()
+}
+
+// MonitorCrash works like the package-level MonitorCrash.
+func (n *Notifier) MonitorCrash(dir string) error {
+	n.crashDir = dir
+	return n.resendCrashes()
+}
+
+// resendCrashes delivers the notices persisted by previous processes.
+func (n *Notifier) resendCrashes() error {
+	if err := os.MkdirAll(n.crashDir, 0700); err != nil {
+		return err
+	}
+	files, err := filepath.Glob(filepath.Join(n.crashDir, "crash-*.json"))
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		notice := new(Notice)
+		if err := json.Unmarshal(data, notice); err != nil {
+			log.Printf("Airbrake error: %s: %s", file, err)
+			os.Remove(file)
+			continue
+		}
+		notice.crashFile = file
+		if err := n.deliver(notice); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// persist writes the filtered notice of a panic to the crash directory, best
+// effort, so that it can be sent on the next start if the process dies first.
+func (n *Notifier) persist(notice *Notice) {
+	if n.crashDir == "" {
+		return
+	}
+	data, err := json.Marshal(notice)
+	if err != nil {
+		return
+	}
+	name := fmt.Sprintf("crash-%d-%d.json", os.Getpid(), time.Now().UnixNano())
+	file := filepath.Join(n.crashDir, name)
+	if ioutil.WriteFile(file, data, 0600) == nil {
+		notice.crashFile = file
+	}
+}
@@ -0,0 +1,91 @@
+package airbrake
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestMonitorCrash(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	n := NewNotifier("key", WithEndpoint(server.URL))
+	if err := n.MonitorCrash(dir); err != nil {
+		t.Fatal(err)
+	}
+	n.WrapFunc(func() { panic("Boom!") })()
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 1 {
+		t.Fatalf("expected the notice to be persisted, got %v", files)
+	}
+
+	// On the next start, the notice is sent again.
+	status = http.StatusCreated
+	if err := NewNotifier("key", WithEndpoint(server.URL)).MonitorCrash(dir); err != nil {
+		t.Fatal(err)
+	}
+	files, _ = filepath.Glob(filepath.Join(dir, "*"))
+	if received != 2 || len(files) != 0 {
+		t.Errorf("expected the notice to be delivered and removed, got %d posts and %v", received, files)
+	}
+
+	n.WrapFunc(func() { panic("Boom!") })()
+	files, _ = filepath.Glob(filepath.Join(dir, "*"))
+	if received != 3 || len(files) != 0 {
+		t.Errorf("expected the notice to be delivered and removed, got %d posts and %v", received, files)
+	}
+}
+
+func TestResendCrashesFailures(t *testing.T) {
+	statuses := []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusCreated
+		if len(statuses) > 0 {
+			status, statuses = statuses[0], statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	n := NewNotifier("key", WithEndpoint(server.URL))
+	n.MonitorCrash(dir)
+	n.WrapFunc(func() { panic("Boom!") })()
+	n.WrapFunc(func() { panic("Boom!") })()
+
+	// A notice failing doesn't keep the others from being sent, and is only
+	// kept if the failure may be transient.
+	for _, sample := range []struct {
+		statuses []int
+		kept     int
+	}{
+		{[]int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}, 2},
+		{[]int{http.StatusUnprocessableEntity}, 0},
+	} {
+		statuses = sample.statuses
+		if err := NewNotifier("key", WithEndpoint(server.URL)).MonitorCrash(dir); err == nil {
+			t.Error("expected an error")
+		}
+		if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != sample.kept {
+			t.Errorf("expected %d files to be kept, got %v", sample.kept, files)
+		}
+	}
+}
+
+func TestMonitorCrashDryRun(t *testing.T) {
+	dir := t.TempDir()
+	n := NewNotifier("key", WithDryRun(true))
+	n.MonitorCrash(dir)
+	n.WrapFunc(func() { panic("Boom!") })()
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("expected no files in dry run, got %v", files)
+	}
+}
@@ -0,0 +1,83 @@
+package airbrake
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxDedupKeys bounds how many distinct errors are tracked. Beyond that,
+// new errors are sent without being deduplicated.
+const maxDedupKeys = 1024
+
+// dedup suppresses the duplicates of the notices sent within a window,
+// counting them, so that the next notice sent once the window has passed
+// tells how many there were.
+type dedup struct {
+	mu   sync.Mutex
+	seen map[string]*occurrences
+}
+
+// occurrences counts the duplicates of a notice sent at since.
+type occurrences struct {
+	since      time.Time
+	suppressed int
+}
+
+// check reports whether the notice with the key is a duplicate to suppress.
+// Otherwise, it returns how many duplicates were suppressed since the previous
+// one was sent, and when that was.
+func (d *dedup) check(key string, window time.Duration, now time.Time) (duplicate bool, suppressed int, since time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if o := d.seen[key]; o != nil {
+		if now.Sub(o.since) < window {
+			o.suppressed++
+			return true, 0, time.Time{}
+		}
+		suppressed, since = o.suppressed, o.since
+		o.since, o.suppressed = now, 0
+		return false, suppressed, since
+	}
+
+	if d.seen == nil {
+		d.seen = make(map[string]*occurrences)
+	}
+	if len(d.seen) >= maxDedupKeys {
+		d.sweep(window, now)
+	}
+	if len(d.seen) < maxDedupKeys {
+		d.seen[key] = &occurrences{since: now}
+	}
+	return false, 0, time.Time{}
+}
+
+// sweep forgets the notices whose window has passed without duplicates.
+func (d *dedup) sweep(window time.Duration, now time.Time) {
+	for key, o := range d.seen {
+		if o.suppressed == 0 && now.Sub(o.since) >= window {
+			delete(d.seen, key)
+		}
+	}
+}
+
+// deduplicate reports whether the notice is a duplicate to drop. Otherwise,
+// it adds the number of duplicates suppressed since the previous one, e.g.
+//
+//	duplicates: seen 1243 times in the last 1m0s
+func (n *Notifier) deduplicate(notice *Notice) bool {
+	if n.dedupWindow <= 0 {
+		return false
+	}
+	now := n.clock.Now()
+	duplicate, suppressed, since := n.state.dedup.check(notice.Key(), n.dedupWindow, now)
+	if duplicate {
+		return true
+	}
+	if suppressed > 0 {
+		notice.request().Params["duplicates"] = fmt.Sprintf("seen %d times in the last %s",
+			suppressed+1, now.Sub(since).Round(time.Second))
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package airbrake
+
+import (
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDedupWindow(t *testing.T) {
+	var received []Notice
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var notice Notice
+		if err := xml.Unmarshal(body, &notice); err != nil {
+			t.Error(err)
+		}
+		received = append(received, notice)
+	}))
+	defer server.Close()
+
+	clock := &fakeClock{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	n := NewNotifier("key", WithEndpoint(server.URL), WithClock(clock), WithDedupWindow(time.Minute))
+	notify := func(message string) { n.Notify(errors.New(message)) }
+
+	for i := 0; i < 5; i++ {
+		notify("Boom!")
+		clock.now = clock.now.Add(time.Second)
+	}
+	notify("Other")
+	clock.now = clock.now.Add(time.Minute)
+	notify("Boom!")
+
+	if len(received) != 3 {
+		t.Fatalf("expected 3 notices, got: %d", len(received))
+	}
+	if received[0].Request != nil && received[0].Request.Params["duplicates"] != "" {
+		t.Errorf("unexpected params: %v", received[0].Request.Params)
+	}
+	if duplicates := received[2].Request.Params["duplicates"]; duplicates != "seen 5 times in the last 1m5s" {
+		t.Errorf("unexpected duplicates: %q", duplicates)
+	}
+	if stats := n.Stats(); stats.Suppressed != 4 || stats.Sent != 3 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,41 @@
+package airbrake
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// diagnostics rate-limits the payloads logged by the DiagnosticsInterval setting.
+type diagnostics struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+// allow reports whether a payload may be logged, at most once per interval.
+func (d *diagnostics) allow(interval time.Duration, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.last.IsZero() && now.Sub(d.last) < interval {
+		return false
+	}
+	d.last = now
+	return true
+}
+
+// diagnose reports whether the payload and response of the notice are logged:
+// with Verbose, for notices marked with Debug, or once per DiagnosticsInterval.
+func (n *Notifier) diagnose(notice *Notice) bool {
+	if n.verbose || notice.Debug {
+		return true
+	}
+	return n.diagnosticsInterval > 0 && n.state.diagnostics.allow(n.diagnosticsInterval, n.clock.Now())
+}
+
+// diagnoseFailure logs the payload of a notice whose delivery failed, with
+// the DiagnoseFailures setting, unless it was already logged.
+func (n *Notifier) diagnoseFailure(notice *Notice, payload []byte, logged bool, err error) {
+	if n.diagnoseFailures && !logged {
+		log.Printf("Airbrake delivery to endpoint %s failed: %s, payload: %s", n.endpointFor(notice), err, payload)
+	}
+}
@@ -0,0 +1,50 @@
+package airbrake
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiagnostics(t *testing.T) {
+	status := http.StatusCreated
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&output)
+
+	clock := &fakeClock{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	n := NewNotifier("key", WithEndpoint(server.URL), WithClock(clock), WithDiagnostics(time.Minute, true),
+		WithFilter(func(notice *Notice) *Notice {
+			notice.Debug = notice.Error.Message == "Debug"
+			return notice
+		}))
+	payloads := func() int { return strings.Count(output.String(), "Airbrake payload") }
+
+	n.Notify(errors.New("First"))
+	n.Notify(errors.New("Second"))
+	if payloads() != 1 {
+		t.Errorf("expected a single payload in a minute:\n%s", output.String())
+	}
+	n.Notify(errors.New("Debug"))
+	clock.now = clock.now.Add(time.Minute)
+	n.Notify(errors.New("Third"))
+	if payloads() != 3 {
+		t.Errorf("expected the debug notice and another minute to be logged:\n%s", output.String())
+	}
+
+	status = http.StatusInternalServerError
+	n.Notify(errors.New("Failed"))
+	if !strings.Contains(output.String(), "failed: 500 Internal Server Error") || payloads() != 3 {
+		t.Errorf("expected the failure to be logged:\n%s", output.String())
+	}
+}
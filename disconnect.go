@@ -0,0 +1,38 @@
+package airbrake
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"syscall"
+)
+
+// disconnect reports whether the error is caused by a peer going away, e.g.
+// a client hanging up before the response was written. See clientGone.
+func disconnect(e error) bool {
+	if e == nil {
+		return false
+	}
+	if errors.Is(e, context.Canceled) || errors.Is(e, http.ErrAbortHandler) ||
+		errors.Is(e, syscall.EPIPE) || errors.Is(e, syscall.ECONNRESET) {
+		return true
+	}
+	// Errors that were flattened to strings along the way.
+	message := e.Error()
+	return strings.Contains(message, "broken pipe") ||
+		strings.Contains(message, "connection reset by peer") ||
+		strings.HasSuffix(message, context.Canceled.Error())
+}
+
+// clientGone reports whether the disconnect of the notice is that of the
+// client of the request: its context was canceled, writing the response
+// failed, or the handler aborted. Other disconnects, e.g. of a database or an upstream service, are
+// not the client's.
+func (n *Notice) clientGone() bool {
+	var responseError *ResponseError
+	if errors.As(n.err, &responseError) && responseError.Op == "write" || errors.Is(n.err, http.ErrAbortHandler) {
+		return true
+	}
+	return n.hungUp != nil && n.hungUp()
+}
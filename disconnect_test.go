@@ -0,0 +1,67 @@
+package airbrake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestDisconnect(t *testing.T) {
+	for e, expected := range map[error]bool{
+		context.Canceled: true,
+		fmt.Errorf("query: %w", context.Canceled):                                              true,
+		&net.OpError{Op: "write", Err: &os.SyscallError{Syscall: "write", Err: syscall.EPIPE}}: true,
+		errors.New("write tcp 10.0.0.1:80->10.0.0.2:5000: write: connection reset by peer"):    true,
+		context.DeadlineExceeded: false,
+		errors.New("Boom!"):      false,
+	} {
+		if result := disconnect(e); result != expected {
+			t.Errorf("%v expected: %v got: %v", e, expected, result)
+		}
+	}
+}
+
+func TestIgnoreDisconnects(t *testing.T) {
+	var reported []*Notice
+	capture := capturing(&reported)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	request := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+
+	ignoring := NewNotifier("key", capture)
+	ignoring.Error(context.Canceled, request)
+	ignoring.Error(&ResponseError{Op: "write", Err: syscall.EPIPE}, httptest.NewRequest("GET", "/", nil))
+	NewNotifier("key", capture, WithIgnoreDisconnects(false)).Error(context.Canceled, request)
+
+	if len(reported) != 1 || reported[0].Severity != SeverityWarning {
+		t.Errorf("unexpected notices: %v", reported)
+	}
+	if ignoring.Stats().Ignored != 2 {
+		t.Errorf("unexpected stats: %+v", ignoring.Stats())
+	}
+}
+
+func TestUpstreamDisconnect(t *testing.T) {
+	var reported []*Notice
+	n := NewNotifier("key", capturing(&reported))
+	reset := &net.OpError{Op: "read", Net: "tcp", Err: &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}}
+	n.Error(fmt.Errorf("querying the database: %w", reset), httptest.NewRequest("GET", "/", nil))
+	n.Notify(context.Canceled)
+
+	if len(reported) != 2 {
+		t.Fatalf("expected 2 notices, got: %d", len(reported))
+	}
+	for _, notice := range reported {
+		if notice.Severity != SeverityWarning {
+			t.Errorf("unexpected severity: %s", notice.Severity)
+		}
+	}
+	if n.Stats().Ignored != 0 {
+		t.Errorf("unexpected stats: %+v", n.Stats())
+	}
+}
@@ -0,0 +1,87 @@
+package airbrake
+
+import "os"
+
+// Enricher adds information to the notices, e.g. about the host, the
+// deployment or the feature flags. Enrichers run before the filters, so
+// that whatever they add is filtered and scrubbed too.
+type Enricher interface {
+	Enrich(*Notice)
+}
+
+// EnricherFunc adapts a function to the Enricher interface.
+type EnricherFunc func(*Notice)
+
+func (f EnricherFunc) Enrich(notice *Notice) { f(notice) }
+
+// Names of the built-in enrichers, which run before the registered ones,
+// unless disabled with the DisabledEnrichers setting.
+const (
+	// HostnameEnricher sets the hostname of the server environment.
+	HostnameEnricher = "hostname"
+
+	// ProjectRootEnricher sets the project root to the working directory.
+	ProjectRootEnricher = "project-root"
+
+	// AppVersionEnricher adds the AppVersion setting to the CGI data of the
+	// notices of requests, so that errbit can link to the commit.
+	AppVersionEnricher = "app-version"
+)
+
+var builtinEnrichers = []struct {
+	name     string
+	enricher func(n *Notifier) Enricher
+}{
+	{HostnameEnricher, func(*Notifier) Enricher { return EnricherFunc(enrichHostname) }},
+	{ProjectRootEnricher, func(*Notifier) Enricher { return EnricherFunc(enrichProjectRoot) }},
+	{AppVersionEnricher, func(n *Notifier) Enricher { return appVersion(n.appVersion) }},
+}
+
+func enrichHostname(notice *Notice) {
+	if hostname, err := os.Hostname(); err == nil {
+		notice.ServerEnvironment.Hostname = hostname
+	}
+}
+
+func enrichProjectRoot(notice *Notice) {
+	if pwd, err := os.Getwd(); err == nil {
+		notice.ServerEnvironment.ProjectRoot = pwd
+	}
+}
+
+type appVersion string
+
+func (v appVersion) Enrich(notice *Notice) {
+	if v != "" && notice.Request != nil {
+		notice.Request.CGIData["APP_VERSION"] = string(v)
+	}
+}
+
+// AddEnricher registers an enricher for the notices sent by the package-level
+// functions. See Notifier.AddEnricher.
+func AddEnricher(enricher Enricher) {
+	enrichers = append(enrichers, enricher)
+}
+
+// AddEnricher registers an enricher that is run on every notice, after the
+// built-in ones and before the filters.
+func (n *Notifier) AddEnricher(enricher Enricher) {
+	n.enrichers = append(n.enrichers, enricher)
+}
+
+// enrich runs the built-in enrichers that are not disabled, then the
+// registered ones.
+func (n *Notifier) enrich(notice *Notice) {
+builtins:
+	for _, builtin := range builtinEnrichers {
+		for _, name := range n.disabledEnrichers {
+			if name == builtin.name {
+				continue builtins
+			}
+		}
+		builtin.enricher(n).Enrich(notice)
+	}
+	for _, enricher := range n.enrichers {
+		enricher.Enrich(notice)
+	}
+}
@@ -0,0 +1,38 @@
+package airbrake
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestEnrichers(t *testing.T) {
+	var reported *Notice
+	n := NewNotifier("key", WithDryRun(true), WithAppVersion("abc123"),
+		WithEnricher(EnricherFunc(func(notice *Notice) {
+			notice.request().Params["feature.checkout"] = "on"
+		})),
+		WithFilter(func(notice *Notice) *Notice {
+			reported = notice
+			return notice
+		}))
+
+	n.Error(errors.New("Boom!"), httptest.NewRequest("GET", "/", nil))
+	hostname, _ := os.Hostname()
+	if reported.ServerEnvironment.Hostname != hostname || reported.ServerEnvironment.ProjectRoot == "" {
+		t.Errorf("unexpected server environment: %+v", reported.ServerEnvironment)
+	}
+	if reported.Request.CGIData["APP_VERSION"] != "abc123" || reported.Request.Params["feature.checkout"] != "on" {
+		t.Errorf("unexpected request: %+v", reported.Request)
+	}
+
+	WithoutEnrichers(HostnameEnricher, AppVersionEnricher)(n)
+	n.Error(errors.New("Boom!"), httptest.NewRequest("GET", "/", nil))
+	if reported.ServerEnvironment.Hostname != "" || reported.ServerEnvironment.ProjectRoot == "" {
+		t.Errorf("unexpected server environment: %+v", reported.ServerEnvironment)
+	}
+	if _, ok := reported.Request.CGIData["APP_VERSION"]; ok {
+		t.Errorf("unexpected request: %+v", reported.Request)
+	}
+}
@@ -0,0 +1,133 @@
+package airbrake
+
+import (
+	"errors"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrorLog returns a logger for http.Server.ErrorLog that reports with the
+// package-level settings. See Notifier.ErrorLog.
+func ErrorLog() *log.Logger {
+	return log.New(&errorLog{defaultNotifier}, "", 0)
+}
+
+// ErrorLog returns a logger for http.Server.ErrorLog, which reports the
+// handler panics recovered by net/http, with their backtrace, unless
+// CapturePanic already did. With the ServerErrors setting, it also reports
+// the other errors of the server, like TLS handshake errors, as warnings.
+// The messages are still written to the standard logger.
+//
+// Example:
+//
+//	server := &http.Server{Handler: handler, ErrorLog: airbrake.ErrorLog()}
+func (n *Notifier) ErrorLog() *log.Logger {
+	return log.New(&errorLog{func() *Notifier { return n }}, "", 0)
+}
+
+type errorLog struct {
+	notifier func() *Notifier
+}
+
+// Write reports a message logged by the server, e.g.
+//
+//	http: panic serving 10.0.0.1:5000: Boom!
+//	goroutine 7 [running]:
+//	...
+func (l *errorLog) Write(p []byte) (int, error) {
+	log.Print(string(p))
+
+	message := strings.TrimSpace(string(p))
+	stack := ""
+	if i := strings.Index(message, "\ngoroutine "); i >= 0 {
+		message, stack = message[:i], message[i+1:]
+	}
+
+	if repanicked(stack) {
+		// Already reported by CapturePanic.
+		return len(p), nil
+	}
+
+	n := l.notifier()
+	rest := strings.TrimPrefix(message, "http: panic serving ")
+	if rest == message && !n.serverErrors {
+		return len(p), nil
+	}
+	notice := n.newNotice(errors.New(strings.TrimPrefix(message, "http: ")), nil, 0)
+	if rest != message {
+		notice.Error.Class = "Panic"
+		if i := strings.Index(rest, ": "); i >= 0 {
+			notice.Client = &Client{IP: rest[:i]}
+			notice.Error.Message = rest[i+2:]
+		}
+		if lines := parseStack(stack); len(lines) > 0 {
+			notice.Error.Backtrace = n.locateAll(lines)
+		}
+	} else {
+		notice.Error.Class = "ServerError"
+		notice.Severity = SeverityWarning
+	}
+	n.send(notice)
+	return len(p), nil
+}
+
+// reporters are the functions re-panicking with the panics they reported.
+var reporters = func() []string {
+	pkg := reflect.TypeOf(Notifier{}).PkgPath()
+	return []string{pkg + ".CapturePanic(", pkg + ".(*Notifier).CapturePanic("}
+}()
+
+// repanicked reports whether the panic of the stack was re-raised by one of
+// the reporters, i.e. whether one of them called panic.
+func repanicked(stack string) bool {
+	rows := strings.Split(stack, "\n")
+	for i := 0; i+2 < len(rows); i++ {
+		if !strings.HasPrefix(rows[i], "panic(") {
+			continue
+		}
+		for _, reporter := range reporters {
+			if strings.HasPrefix(rows[i+2], reporter) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseStack parses a goroutine stack, as printed by runtime/debug.Stack,
+// starting after the panic.
+func parseStack(stack string) []Line {
+	var lines []Line
+	rows := strings.Split(stack, "\n")
+	for i := 1; i+1 < len(rows); i += 2 {
+		function, location := rows[i], strings.TrimSpace(rows[i+1])
+		if !strings.HasPrefix(rows[i+1], "\t") {
+			break
+		}
+		function = strings.TrimPrefix(function, "created by ")
+		if j := strings.Index(function, " in goroutine "); j >= 0 {
+			function = function[:j]
+		}
+		if j := strings.LastIndexByte(function, '('); j > 0 && strings.HasSuffix(function, ")") {
+			function = function[:j]
+		}
+		if j := strings.LastIndex(location, " +0x"); j >= 0 {
+			location = location[:j]
+		}
+
+		line := newLine(function, location, 0)
+		if j := strings.LastIndexByte(location, ':'); j >= 0 {
+			line.File = location[:j]
+			line.Line, _ = strconv.Atoi(location[j+1:])
+		}
+		if line.Function == "panic" {
+			// Start over from the panicking frame.
+			lines = lines[:0]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
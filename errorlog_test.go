@@ -0,0 +1,51 @@
+package airbrake
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorLog(t *testing.T) {
+	var reported []*Notice
+	n := NewNotifier("key", capturing(&reported))
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(errors.New("Boom!"))
+	}))
+	server.Config.ErrorLog = n.ErrorLog()
+	server.Start()
+	defer server.Close()
+
+	if _, err := http.Get(server.URL); err == nil {
+		t.Fatal("expected the connection to be closed by the panic")
+	}
+	server.Close()
+
+	if len(reported) != 1 {
+		t.Fatalf("unexpected notices: %d", len(reported))
+	}
+	notice := reported[0]
+	if notice.Error.Class != "Panic" || notice.Error.Message != "Boom!" || notice.Client == nil || notice.Client.IP == "" {
+		t.Errorf("unexpected notice: %+v %+v", notice.Error, notice.Request)
+	}
+	if top := notice.Error.Backtrace[0]; top.Function != "airbrake-go.TestErrorLog.func1" || top.Line == 0 {
+		t.Errorf("expected the panicking frame on top, got: %+v", top)
+	}
+}
+
+func TestErrorLogServerError(t *testing.T) {
+	var reported []*Notice
+	NewNotifier("key", capturing(&reported)).ErrorLog().Printf("http: TLS handshake error from 10.0.0.1:5000: EOF")
+	if len(reported) != 0 {
+		t.Errorf("expected server errors to be ignored by default, got: %+v", reported)
+	}
+
+	n := NewNotifier("key", capturing(&reported), WithServerErrors(true))
+	n.ErrorLog().Printf("http: TLS handshake error from 10.0.0.1:5000: EOF")
+	if len(reported) != 1 || reported[0].Error.Class != "ServerError" || reported[0].Severity != SeverityWarning ||
+		reported[0].Error.Message != "TLS handshake error from 10.0.0.1:5000: EOF" {
+		t.Errorf("unexpected notices: %+v", reported)
+	}
+}
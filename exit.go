@@ -0,0 +1,126 @@
+package airbrake
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// exitTimeout bounds how long Fatal and NotifyOnExit wait for delivery.
+const exitTimeout = 10 * time.Second
+
+// Fatal logs and reports the error, waits until it and any queued notices
+// have been delivered or timed out, and exits with status 1.
+// It is meant for short-lived commands and batch jobs.
+func Fatal(e error) {
+	n := defaultNotifier()
+	n.fatal(n.newNotice(e, nil, 0))
+}
+
+// Fatal works like the package-level Fatal.
+func (n *Notifier) Fatal(e error) {
+	n.fatal(n.newNotice(e, nil, 0))
+}
+
+func (n *Notifier) fatal(notice *Notice) {
+	log.Print(notice.Error.Message)
+	n.sync(func(s *Notifier) bool { return s.send(notice) == nil })
+	os.Exit(1)
+}
+
+// NotifyOnExit is meant to be deferred at the top of main. It reports a panic,
+// waits until it and any queued notices have been delivered or timed out,
+// and then lets the panic continue.
+//
+// Example:
+//
+//	func main() {
+//	    defer airbrake.NotifyOnExit()
+//	    ...
+//	}
+func NotifyOnExit() {
+	if rec := recover(); rec != nil {
+		n := defaultNotifier()
+		panic(n.reported(rec, n.sync(func(s *Notifier) bool { return s.capture(rec, nil) })))
+	}
+	Flush(exitTimeout)
+}
+
+// NotifyOnExit works like the package-level NotifyOnExit.
+func (n *Notifier) NotifyOnExit() {
+	if rec := recover(); rec != nil {
+		panic(n.reported(rec, n.sync(func(s *Notifier) bool { return s.capture(rec, nil) })))
+	}
+	n.Flush(exitTimeout)
+}
+
+// FlushOnSignal flushes the notices queued by the package-level functions
+// on the signals. See Notifier.FlushOnSignal.
+func FlushOnSignal(signals ...os.Signal) (stop func()) {
+	return flushOnSignal(func() { Flush(exitTimeout) }, signals)
+}
+
+// FlushOnSignal installs a handler that, on one of the signals, or SIGINT and
+// SIGTERM if none are given, waits until the queued notices have been
+// delivered or timed out, then delivers the signal again to terminate the
+// process as it would have been. It is meant for asynchronous delivery in
+// containers, which are stopped with SIGTERM. Applications handling these
+// signals themselves should rather call Flush when shutting down.
+// stop uninstalls the handler.
+func (n *Notifier) FlushOnSignal(signals ...os.Signal) (stop func()) {
+	return flushOnSignal(func() { n.Flush(exitTimeout) }, signals)
+}
+
+func flushOnSignal(flush func(), signals []os.Signal) func() {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, signals...)
+
+	done := make(chan struct{})
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+
+	go func() {
+		select {
+		case sig := <-c:
+			flush()
+			stop()
+			if p, err := os.FindProcess(os.Getpid()); err != nil || p.Signal(sig) != nil {
+				os.Exit(1)
+			}
+		case <-done:
+		}
+	}()
+	return stop
+}
+
+// sync runs report on a copy of the notifier with asynchronous delivery
+// disabled, then flushes the queue, giving up after exitTimeout. It returns
+// the result of report, or false if it gave up.
+func (n *Notifier) sync(report func(*Notifier) bool) bool {
+	synchronous := *n
+	synchronous.queueSize = 0
+
+	deadline := time.Now().Add(exitTimeout)
+	done := make(chan bool, 1)
+	go func() {
+		done <- report(&synchronous)
+	}()
+	select {
+	case reported := <-done:
+		n.Flush(time.Until(deadline))
+		return reported
+	case <-time.After(exitTimeout):
+		return false
+	}
+}
@@ -0,0 +1,67 @@
+package airbrake
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"testing"
+	"time"
+)
+
+func TestNotifyOnExit(t *testing.T) {
+	var reported []string
+	n := NewNotifier("key", WithQueueSize(10), WithFilter(func(notice *Notice) *Notice {
+		reported = append(reported, notice.Error.Message)
+		return nil
+	}))
+
+	defer func() {
+		if rec := recover(); rec == nil {
+			t.Error("expected the panic to continue")
+		}
+		if len(reported) != 1 || reported[0] != "Boom!" {
+			t.Errorf("unexpected notices: %v", reported)
+		}
+		if stats := n.Stats(); stats.Dropped != 0 || !n.Flush(time.Millisecond) {
+			t.Errorf("expected synchronous delivery, got: %+v", stats)
+		}
+	}()
+	defer n.NotifyOnExit()
+	panic(errors.New("Boom!"))
+}
+
+func TestFlushOnSignal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	// Catch the signal here too, or it would terminate the test.
+	caught := make(chan os.Signal, 2)
+	signal.Notify(caught, os.Interrupt)
+	defer signal.Stop(caught)
+
+	n := NewNotifier("key", WithEndpoint(server.URL), WithQueueSize(10))
+	defer n.FlushOnSignal(os.Interrupt)()
+	for i := 0; i < 3; i++ {
+		n.Notify(errors.New("Boom!"))
+	}
+
+	p, _ := os.FindProcess(os.Getpid())
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Skip("cannot signal the process:", err)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-caught:
+		case <-time.After(exitTimeout):
+			t.Fatal("expected the signal to be delivered again")
+		}
+	}
+
+	if stats := n.Stats(); stats.Sent != 3 {
+		t.Errorf("expected the queue to be flushed, got: %+v", stats)
+	}
+}
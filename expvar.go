@@ -0,0 +1,25 @@
+package airbrake
+
+import "expvar"
+
+// PublishExpvar publishes the stats and delivery latencies of the
+// package-level functions. See Notifier.PublishExpvar.
+func PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return published{CurrentStats(), CurrentLatency()}
+	}))
+}
+
+// PublishExpvar publishes the stats and delivery latencies of the notifier
+// as an expvar, e.g. to be served on /debug/vars. Like expvar.Publish, it
+// panics if the name is already in use.
+func (n *Notifier) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return published{n.Stats(), n.Latency()}
+	}))
+}
+
+type published struct {
+	Stats
+	Latency Histogram
+}
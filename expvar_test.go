@@ -0,0 +1,29 @@
+package airbrake
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	// Names can be published only once per process, even with -count.
+	name := fmt.Sprintf("airbrake_test_%d", time.Now().UnixNano())
+	n := NewNotifier("key", WithDryRun(true))
+	n.PublishExpvar(name)
+	n.Notify(errors.New("Boom!"))
+
+	var published struct {
+		DryRun  uint64
+		Latency struct{ Count uint64 }
+	}
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &published); err != nil {
+		t.Fatal(err)
+	}
+	if published.DryRun != 1 || published.Latency.Count != 0 {
+		t.Errorf("unexpected vars: %+v", published)
+	}
+}
@@ -0,0 +1,124 @@
+package airbrake
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GoldenNotices returns the notices the golden files of CheckGolden are
+// rendered from, by name: a full notice of a request, the same notice in
+// minimal mode (see MinimalFrames), and a message without a request. They
+// are built from fixed values, so that their payloads are reproducible.
+func GoldenNotices() map[string]*Notice {
+	full := func() *Notice {
+		return &Notice{
+			Version:  "2.0",
+			ApiKey:   "0123456789abcdef",
+			Notifier: notifierInfo{"Airbrake Golang", "0.0.1", "http://airbrake.io"},
+			Error: errorInfo{
+				Class:   "*errors.errorString",
+				Message: "Boom!",
+				Backtrace: []Line{
+					{Function: "(*Invoice).Total", File: "[PROJECT_ROOT]/models/invoice.go", Line: 42,
+						Package: "github.com/user/project/models", Receiver: "*Invoice", Name: "Total"},
+					{Function: "ShowInvoice", File: "[PROJECT_ROOT]/handlers/invoices.go", Line: 17,
+						Package: "github.com/user/project/handlers", Name: "ShowInvoice"},
+				},
+			},
+			Request: &request{
+				URL:       "https://example.com/invoices/1?user[name]=Jane&tags[0]=a",
+				Component: "handlers",
+				Action:    "ShowInvoice",
+				Params:    vars{"user[name]": "Jane", "tags[0]": "a", "request_id": "f00"},
+				Session:   vars{"tenant": "acme"},
+				CGIData:   vars{"HTTP_USER_AGENT": "curl/7.64.1", "REQUEST_METHOD": "GET"},
+			},
+			ServerEnvironment: serverEnvironment{
+				ProjectRoot:     "/srv/project",
+				EnvironmentName: "production",
+				Hostname:        "web-1",
+			},
+			User:     &User{ID: "1", Name: "Jane Doe", Email: "jane@example.com"},
+			Severity: SeverityError,
+			Time:     time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		}
+	}
+
+	minimal := full()
+	minimal.minimize(1)
+
+	message := full()
+	message.Error = errorInfo{Class: "Deploy", Message: "Deployed 1.2.3"}
+	message.Request, message.User = nil, nil
+	message.Severity = SeverityWarning
+
+	return map[string]*Notice{"full": full(), "minimal": minimal, "message": message}
+}
+
+// CheckGolden renders each of the GoldenNotices with the serializer, and
+// compares the payload to the golden file <name>.golden in dir, e.g. to check
+// that a custom serializer still renders what a collector accepts. With
+// update, the golden files are written instead. The error lists the notices
+// whose payload differs, with the first line that does.
+func CheckGolden(s Serializer, dir string, update bool) error {
+	notices := GoldenNotices()
+	names := make([]string, 0, len(notices))
+	for name := range notices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		path := filepath.Join(dir, name+".golden")
+		payload, err := s.Serialize(notices[name])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		if update {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(path, payload, 0644); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		golden, err := ioutil.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !bytes.Equal(payload, golden) {
+			errs = append(errs, fmt.Errorf("%s: %s", path, difference(string(payload), string(golden))))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// difference describes the first line of the payload that differs from the
+// golden file.
+func difference(payload, golden string) string {
+	got, want := strings.Split(payload, "\n"), strings.Split(golden, "\n")
+	for i := 0; i < len(got) || i < len(want); i++ {
+		var g, w string
+		if i < len(got) {
+			g = got[i]
+		}
+		if i < len(want) {
+			w = want[i]
+		}
+		if g != w {
+			return fmt.Sprintf("line %d is %q, want %q", i+1, g, w)
+		}
+	}
+	return "payloads differ"
+}
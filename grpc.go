@@ -0,0 +1,93 @@
+package airbrake
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// grpcCodes are the names of the gRPC status codes, indexed by code.
+var grpcCodes = []string{
+	"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded",
+	"NotFound", "AlreadyExists", "PermissionDenied", "ResourceExhausted",
+	"FailedPrecondition", "Aborted", "OutOfRange", "Unimplemented",
+	"Internal", "Unavailable", "DataLoss", "Unauthenticated",
+}
+
+// GRPCSeverity maps a gRPC status code to a severity: codes caused by the
+// client, like InvalidArgument or NotFound, are warnings, DataLoss is
+// critical, and the others are errors. It returns "" for OK.
+func GRPCSeverity(code uint32) string {
+	switch code {
+	case 0:
+		return ""
+	case 1, 3, 4, 5, 6, 7, 8, 9, 10, 11, 16:
+		return SeverityWarning
+	case 15:
+		return SeverityCritical
+	}
+	return SeverityError
+}
+
+// grpcStatus is the status of an error implementing GRPCStatus(), like
+// the errors returned by google.golang.org/grpc/status. It is read with
+// reflection, so the notifier does not depend on the grpc packages.
+type grpcStatus struct {
+	code    uint32
+	message string
+	details []interface{}
+}
+
+// statusOf returns the gRPC status in the error chain, if any.
+func statusOf(e error) (grpcStatus, bool) {
+	for ; e != nil; e = errors.Unwrap(e) {
+		method := reflect.ValueOf(e).MethodByName("GRPCStatus")
+		if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+			continue
+		}
+		s := method.Call(nil)[0]
+		if s.Kind() == reflect.Ptr && s.IsNil() {
+			continue
+		}
+
+		var status grpcStatus
+		if code := s.MethodByName("Code"); code.IsValid() && code.Type().NumIn() == 0 {
+			if v := code.Call(nil)[0]; v.CanUint() {
+				status.code = uint32(v.Uint())
+			}
+		}
+		if message := s.MethodByName("Message"); message.IsValid() && message.Type().NumIn() == 0 {
+			status.message = message.Call(nil)[0].String()
+		}
+		if details := s.MethodByName("Details"); details.IsValid() && details.Type().NumIn() == 0 {
+			status.details, _ = details.Call(nil)[0].Interface().([]interface{})
+		}
+		return status, true
+	}
+	return grpcStatus{}, false
+}
+
+// addGRPC classifies the notice by the gRPC status code of the error,
+// if any, and lists the status details in the params.
+func addGRPC(notice *Notice, e error) {
+	status, ok := statusOf(e)
+	if !ok {
+		return
+	}
+	name := "Code(" + strconv.FormatUint(uint64(status.code), 10) + ")"
+	if int(status.code) < len(grpcCodes) {
+		name = grpcCodes[status.code]
+	}
+	notice.Error.Class = "grpc." + name
+	notice.Error.Message = status.message
+	if severity := GRPCSeverity(status.code); severity != "" {
+		notice.Severity = severity
+	}
+
+	params := notice.request().Params
+	params["grpc.code"] = name
+	for i, detail := range status.details {
+		params["grpc.details."+strconv.Itoa(i)] = fmt.Sprint(detail)
+	}
+}
@@ -0,0 +1,58 @@
+package airbrake
+
+import (
+	"fmt"
+	"testing"
+)
+
+type testCode uint32
+
+type testStatus struct {
+	code    testCode
+	message string
+	details []interface{}
+}
+
+func (s *testStatus) Code() testCode         { return s.code }
+func (s *testStatus) Message() string        { return s.message }
+func (s *testStatus) Details() []interface{} { return s.details }
+
+type testStatusError struct{ status *testStatus }
+
+func (e *testStatusError) Error() string {
+	return fmt.Sprintf("rpc error: code = %d desc = %s", e.status.code, e.status.message)
+}
+
+func (e *testStatusError) GRPCStatus() *testStatus { return e.status }
+
+func TestGRPCStatus(t *testing.T) {
+	status := &testStatus{3, "name is required", []interface{}{"field: name"}}
+	e := fmt.Errorf("create user: %w", &testStatusError{status})
+
+	notice := NewNotifier("key").newNotice(e, nil, 0)
+
+	if notice.Error.Class != "grpc.InvalidArgument" || notice.Error.Message != "name is required" {
+		t.Errorf("unexpected error: %+v", notice.Error)
+	}
+	if notice.Severity != SeverityWarning {
+		t.Errorf("unexpected severity: %s", notice.Severity)
+	}
+	params := notice.Request.Params
+	if params["grpc.code"] != "InvalidArgument" || params["grpc.details.0"] != "field: name" {
+		t.Errorf("unexpected params: %v", params)
+	}
+}
+
+func TestGRPCSeverity(t *testing.T) {
+	for code, expected := range map[uint32]string{
+		0:  "",
+		3:  SeverityWarning,
+		13: SeverityError,
+		15: SeverityCritical,
+		99: SeverityError,
+	} {
+		if severity := GRPCSeverity(code); severity != expected {
+			t.Errorf("%d expected: %q got: %q", code, expected, severity)
+		}
+	}
+}
@@ -15,3 +15,49 @@ func CapturePanicHandler(app http.HandlerFunc) http.HandlerFunc {
 		app(w, r)
 	}
 }
+
+// RecoveryHandler "middleware".
+// Wraps the http handler so that panics are reported to airbrake and answered
+// with a 500 response, instead of leaving net/http to abort the connection.
+// The response is served by errorHandler, or is a plain text error if nil.
+// If the handler already started the response, it cannot be replaced, and
+// the connection is aborted, so that the client does not take the partial
+// response for a complete one.
+//
+// Example:
+//
+//	http.Handle("/", airbrake.RecoveryHandler(mux, errorPage))
+func RecoveryHandler(app http.Handler, errorHandler http.Handler) http.Handler {
+	return recoveryHandler(defaultNotifier, app, errorHandler)
+}
+
+// RecoveryHandler works like the package-level RecoveryHandler.
+func (n *Notifier) RecoveryHandler(app http.Handler, errorHandler http.Handler) http.Handler {
+	return recoveryHandler(func() *Notifier { return n }, app, errorHandler)
+}
+
+func recoveryHandler(notifier func() *Notifier, app http.Handler, errorHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			notifier().capture(rec, r)
+			if recorder.written {
+				panic(http.ErrAbortHandler)
+			}
+			if errorHandler != nil {
+				errorHandler.ServeHTTP(w, r)
+			} else {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+		app.ServeHTTP(recorder, r)
+	})
+}
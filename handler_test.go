@@ -0,0 +1,64 @@
+package airbrake
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecoveryHandler(t *testing.T) {
+	var reported []string
+	n := NewNotifier("key", WithFilter(func(notice *Notice) *Notice {
+		reported = append(reported, notice.Error.Message)
+		return nil
+	}))
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(errors.New("Boom!"))
+	})
+	errorPage := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("Sorry"))
+	})
+
+	w := httptest.NewRecorder()
+	n.RecoveryHandler(app, errorPage).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "Sorry" {
+		t.Errorf("unexpected response: %d %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	n.RecoveryHandler(app, nil).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected response: %d %s", w.Code, w.Body)
+	}
+
+	if len(reported) != 2 || reported[0] != "Boom!" {
+		t.Errorf("unexpected notices: %v", reported)
+	}
+}
+
+func TestRecoveryHandlerStartedResponse(t *testing.T) {
+	var reported []*Notice
+	n := NewNotifier("key", capturing(&reported))
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic(errors.New("Boom!"))
+	})
+
+	w := httptest.NewRecorder()
+	func() {
+		defer func() {
+			if rec := recover(); rec != http.ErrAbortHandler {
+				t.Errorf("expected the response to be aborted, got: %v", rec)
+			}
+		}()
+		n.RecoveryHandler(app, nil).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	}()
+	if w.Code != http.StatusOK || w.Body.String() != "partial" {
+		t.Errorf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	if len(reported) != 1 {
+		t.Errorf("expected 1 notice, got: %d", len(reported))
+	}
+}
@@ -0,0 +1,47 @@
+package airbrake
+
+import "strconv"
+
+// JobContext describes the background job that failed, for workers that
+// have no http request to report.
+type JobContext struct {
+	Queue   string
+	JobID   string
+	Args    map[string]interface{}
+	Attempt int
+}
+
+// ErrorWithJob reports the error of a background job. See
+// Notifier.ErrorWithJob.
+func ErrorWithJob(e error, job JobContext) error {
+	n := defaultNotifier()
+	notice := n.newNotice(e, nil, 0)
+	addJob(notice, job)
+	return n.send(notice)
+}
+
+// ErrorWithJob reports the error of a background job. The notice has the
+// component "job" and the queue as action, so errors group by queue, and
+// the job ID, attempt and args as params. Sensitive args are omitted.
+func (n *Notifier) ErrorWithJob(e error, job JobContext) error {
+	notice := n.newNotice(e, nil, 0)
+	addJob(notice, job)
+	return n.send(notice)
+}
+
+func addJob(notice *Notice, job JobContext) {
+	r := notice.request()
+	r.Component = "job"
+	r.Action = job.Queue
+	if job.JobID != "" {
+		r.Params["job_id"] = job.JobID
+	}
+	if job.Attempt > 0 {
+		r.Params["attempt"] = strconv.Itoa(job.Attempt)
+	}
+	args := make(map[string]interface{}, len(job.Args))
+	for k, v := range job.Args {
+		args["args["+k+"]"] = v
+	}
+	addFields(notice, args)
+}
@@ -0,0 +1,28 @@
+package airbrake
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorWithJob(t *testing.T) {
+	var reported *Notice
+	n := NewNotifier("key", WithFilter(func(notice *Notice) *Notice {
+		reported = notice
+		return nil
+	}))
+	n.ErrorWithJob(errors.New("Boom!"), JobContext{
+		Queue:   "mailers",
+		JobID:   "42",
+		Args:    map[string]interface{}{"user": 7, "token": "xyz"},
+		Attempt: 3,
+	})
+
+	r := reported.Request
+	if r.Component != "job" || r.Action != "mailers" {
+		t.Errorf("unexpected component and action: %s#%s", r.Component, r.Action)
+	}
+	if len(r.Params) != 3 || r.Params["job_id"] != "42" || r.Params["attempt"] != "3" || r.Params["args[user]"] != "7" {
+		t.Errorf("unexpected params: %v", r.Params)
+	}
+}
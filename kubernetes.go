@@ -0,0 +1,71 @@
+package airbrake
+
+import (
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	kubernetesOnce     sync.Once
+	kubernetesMetadata map[string]string
+
+	containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+)
+
+// kubernetes returns the pod metadata, detected once. The pod name, namespace
+// and node are read from the conventional downward API environment variables,
+// e.g.
+//
+//	env:
+//	- name: POD_NAME
+//	  valueFrom: {fieldRef: {fieldPath: metadata.name}}
+//
+// falling back to the hostname (which defaults to the pod name) and the
+// service account namespace. The container ID is parsed from the cgroups.
+func kubernetes() map[string]string {
+	kubernetesOnce.Do(func() {
+		kubernetesMetadata = make(map[string]string)
+		if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+			return
+		}
+
+		pod := os.Getenv("POD_NAME")
+		if pod == "" {
+			pod, _ = os.Hostname()
+		}
+		namespace := os.Getenv("POD_NAMESPACE")
+		if namespace == "" {
+			b, _ := ioutil.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+			namespace = strings.TrimSpace(string(b))
+		}
+		cgroup, _ := ioutil.ReadFile("/proc/self/cgroup")
+
+		for k, v := range map[string]string{
+			"K8S_POD_NAME":        pod,
+			"K8S_NAMESPACE":       namespace,
+			"K8S_NODE_NAME":       os.Getenv("NODE_NAME"),
+			"K8S_CONTAINER_ID":    containerID(string(cgroup)),
+			"K8S_POD_IP":          os.Getenv("POD_IP"),
+			"K8S_SERVICE_ACCOUNT": os.Getenv("POD_SERVICE_ACCOUNT"),
+		} {
+			if v != "" {
+				kubernetesMetadata[k] = v
+			}
+		}
+	})
+	return kubernetesMetadata
+}
+
+// containerID finds the container ID in the contents of /proc/self/cgroup, e.g.
+//
+//	0::/kubepods/besteffort/pod0c4e.../cri-containerd-<id>.scope
+func containerID(cgroup string) string {
+	ids := containerIDPattern.FindAllString(cgroup, -1)
+	if len(ids) == 0 {
+		return ""
+	}
+	return ids[len(ids)-1]
+}
@@ -0,0 +1,18 @@
+package airbrake
+
+import "testing"
+
+func TestContainerID(t *testing.T) {
+	id := "3e1f2c0a9b8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f"
+	for _, cgroup := range []string{
+		"0::/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod1234.slice/cri-containerd-" + id + ".scope\n",
+		"12:memory:/kubepods/burstable/pod0c4e7b1a-7f5e-4bd1-9b8e-2f6c1a3d5e7f/" + id + "\n",
+	} {
+		if result := containerID(cgroup); result != id {
+			t.Errorf("expected: %s got: %s", id, result)
+		}
+	}
+	if result := containerID("0::/user.slice\n"); result != "" {
+		t.Errorf("expected no container, got: %s", result)
+	}
+}
@@ -0,0 +1,112 @@
+package airbrake
+
+import (
+	"path"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+var (
+	// mainModule is the path of the main module, e.g. github.com/user/project,
+	// and mainPackage the path of the main package, from the build info.
+	mainModule, mainPackage = func() (string, string) {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			return info.Main.Path, info.Path
+		}
+		return "", ""
+	}()
+
+	// moduleRoot is the directory of the main module, see learnRoot.
+	moduleRoot atomic.Value
+)
+
+// learnRoot records the directory of the main module, when seeing a frame
+// of it for the first time: the directory of the file, without the path of
+// its package within the module. In -trimpath builds, the files are named
+// after the import paths, so that is the module path itself.
+func learnRoot(name, file string) {
+	if mainModule == "" || moduleRoot.Load() != nil {
+		return
+	}
+	pkg := packagePath(name)
+	if pkg == "main" {
+		pkg = mainPackage
+	}
+	if pkg != mainModule && !strings.HasPrefix(pkg, mainModule+"/") {
+		return
+	}
+	dir := path.Dir(file)
+	if sub := strings.TrimPrefix(pkg, mainModule); strings.HasSuffix(dir, sub) {
+		moduleRoot.Store(strings.TrimSuffix(dir, sub))
+	}
+}
+
+// root returns the project root used to locate files: the RootPackage
+// setting, or else the directory of the main module, once known.
+func (n *Notifier) root() string {
+	if n.rootPackage != "" {
+		return n.rootPackage
+	}
+	root, _ := moduleRoot.Load().(string)
+	return root
+}
+
+// rootPackage is a root package of the RootPackages setting, and the
+// directory of the repository it is in, as /dir, or "" for the top.
+type rootPackage struct {
+	path, dir string
+}
+
+// sortRoots returns the root packages, longest first, so that nested
+// modules take precedence over the modules containing them.
+func sortRoots(roots map[string]string) []rootPackage {
+	if len(roots) == 0 {
+		return nil
+	}
+	sorted := make([]rootPackage, 0, len(roots))
+	for p, dir := range roots {
+		if dir = strings.Trim(dir, "/"); dir != "" {
+			dir = "/" + dir
+		}
+		sorted = append(sorted, rootPackage{p, dir})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i].path) != len(sorted[j].path) {
+			return len(sorted[i].path) > len(sorted[j].path)
+		}
+		return sorted[i].path < sorted[j].path
+	})
+	return sorted
+}
+
+// dependency shortens the paths of files in the module cache, to the
+// module@version/file form, and in vendor directories, to vendor/file.
+// It reports whether the file is a dependency at all.
+func dependency(file string) (string, bool) {
+	if i := strings.LastIndex(file, "/pkg/mod/"); i >= 0 {
+		return file[i+len("/pkg/mod/"):], true
+	}
+	if i := strings.LastIndex(file, "/vendor/"); i >= 0 {
+		return file[i+1:], true
+	}
+	// -trimpath builds name the files of modules like module@version/file.
+	if !path.IsAbs(file) && strings.Contains(path.Dir(file), "@") {
+		return file, true
+	}
+	return file, false
+}
+
+// packagePath returns the import path of the package of a function, from
+// its full name, e.g. net/http for net/http.(*Server).Serve.
+func packagePath(name string) string {
+	dir, base := "", name
+	if slash := strings.LastIndex(name, "/"); slash >= 0 {
+		dir, base = name[:slash+1], name[slash+1:]
+	}
+	if dot := strings.Index(base, "."); dot >= 0 {
+		base = base[:dot]
+	}
+	return dir + base
+}
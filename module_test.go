@@ -0,0 +1,72 @@
+package airbrake
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPackagePath(t *testing.T) {
+	for name, expected := range map[string]string{
+		"net/http.(*Server).Serve": "net/http",
+		"main.main":                "main",
+		"github.com/tobi/airbrake-go.Notify.func1": "github.com/tobi/airbrake-go",
+	} {
+		if result := packagePath(name); result != expected {
+			t.Errorf("%s expected: %s got: %s", name, expected, result)
+		}
+	}
+}
+
+func TestModuleRoot(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	stacktrace(0, false)
+
+	if mainModule == "" {
+		t.Skip("no build info")
+	}
+	root := NewNotifier("key").root()
+	if root == "" || !strings.HasPrefix(file, root) {
+		t.Errorf("unexpected root: %q for %s", root, file)
+	}
+	if located := NewNotifier("key").locate(file); located != "[PROJECT_ROOT]/module_test.go" {
+		t.Errorf("unexpected location: %s", located)
+	}
+}
+
+func TestDependency(t *testing.T) {
+	for _, sample := range []struct {
+		in, out    string
+		dependency bool
+	}{
+		{"/home/ci/go/pkg/mod/github.com/foo/bar@v1.2.3/baz.go", "github.com/foo/bar@v1.2.3/baz.go", true},
+		{"/build/app/vendor/github.com/foo/bar/baz.go", "vendor/github.com/foo/bar/baz.go", true},
+		{"github.com/foo/bar@v1.2.3/baz.go", "github.com/foo/bar@v1.2.3/baz.go", true},
+		{"/build/app/handler.go", "/build/app/handler.go", false},
+	} {
+		if out, dependency := dependency(sample.in); out != sample.out || dependency != sample.dependency {
+			t.Errorf("%s expected: %s %v got: %s %v", sample.in, sample.out, sample.dependency, out, dependency)
+		}
+	}
+}
+
+func TestRootPackages(t *testing.T) {
+	n := NewNotifier("key", WithRootPackage("github.com/user/mono"), WithRootPackages(map[string]string{
+		"github.com/user/mono/billing":      "billing/",
+		"github.com/user/mono/billing/api":  "/billing/api",
+		"github.com/user/mono-tools/lint":   "",
+		"github.com/user/mono/pkg/payments": "pkg/payments",
+	}))
+	for in, out := range map[string]string{
+		"/src/github.com/user/mono/cmd/server/main.go":         "[PROJECT_ROOT]/cmd/server/main.go",
+		"/src/github.com/user/mono/billing/invoice.go":         "[PROJECT_ROOT]/billing/invoice.go",
+		"/src/github.com/user/mono/billing/api/handler.go":     "[PROJECT_ROOT]/billing/api/handler.go",
+		"/src/github.com/user/mono/pkg/payments/charge.go":     "[PROJECT_ROOT]/pkg/payments/charge.go",
+		"/src/github.com/user/mono-tools/lint/lint.go":         "[PROJECT_ROOT]/lint.go",
+		"/go/pkg/mod/github.com/user/mono/billing@v1.0.0/x.go": "github.com/user/mono/billing@v1.0.0/x.go",
+	} {
+		if located := n.locate(in); located != out {
+			t.Errorf("%s: expected %s, got: %s", in, out, located)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package airbrake
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// class returns the class reported for the error.
+func class(e error) string {
+	if c := reflect.TypeOf(e).String(); c != "" {
+		return c
+	}
+	return "Panic"
+}
+
+// unjoin flattens errors created by errors.Join, and other errors implementing
+// Unwrap() []error, into their constituent errors.
+func unjoin(e error) []error {
+	multi, ok := e.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{e}
+	}
+	var errs []error
+	for _, err := range multi.Unwrap() {
+		if err != nil {
+			errs = append(errs, unjoin(err)...)
+		}
+	}
+	return errs
+}
+
+// addErrors lists the constituent errors of a multi-error in the params,
+// as errors.0, errors.1, ... so they remain distinguishable.
+func addErrors(notice *Notice, errs []error, class func(error) string) {
+	params := notice.request().Params
+	for i, e := range errs {
+		params["errors."+strconv.Itoa(i)] = class(e) + ": " + e.Error()
+	}
+}
+
+// with returns a copy of the notice reporting the error instead.
+func (n *Notice) with(e error) *Notice {
+	c := n.copy()
+	c.err = e
+	c.Error.Class = class(e)
+	c.Error.Message = e.Error()
+	return c
+}
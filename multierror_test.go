@@ -0,0 +1,34 @@
+package airbrake
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMultiErrorParams(t *testing.T) {
+	joined := errors.Join(errors.New("first"), errors.Join(errors.New("second"), errors.New("third")))
+	notice := defaultNotifier().newNotice(joined, nil, 0)
+
+	expected := vars{
+		"errors.0": "*errors.errorString: first",
+		"errors.1": "*errors.errorString: second",
+		"errors.2": "*errors.errorString: third",
+	}
+	if !reflect.DeepEqual(notice.Request.Params, expected) {
+		t.Errorf("unexpected params: %v", notice.Request.Params)
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	var messages []string
+	n := NewNotifier("key", WithSplitErrors(true), WithFilter(func(notice *Notice) *Notice {
+		messages = append(messages, notice.Error.Message)
+		return nil
+	}))
+
+	n.Notify(errors.Join(errors.New("first"), errors.New("second")))
+	if !reflect.DeepEqual(messages, []string{"first", "second"}) {
+		t.Errorf("unexpected notices: %v", messages)
+	}
+}
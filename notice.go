@@ -0,0 +1,298 @@
+package airbrake
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Notice is the XML document accepted by the notifier API.
+// See https://help.airbrake.io/kb/api-2/notifier-api-version-23
+type Notice struct {
+	XMLName           xml.Name          `xml:"notice"`
+	Version           string            `xml:"version,attr"`
+	ApiKey            string            `xml:"api-key"`
+	Notifier          notifierInfo      `xml:"notifier"`
+	Error             errorInfo         `xml:"error"`
+	Request           *request          `xml:"request,omitempty"`
+	ServerEnvironment serverEnvironment `xml:"server-environment"`
+
+	// User is the user affected by the error, see ExtractUser.
+	User *User `xml:"current-user,omitempty"`
+
+	// Client is who made the request of the notice, if any. The notifier API
+	// has no element for it, so it is sent as the REMOTE_ADDR, HTTP_USER_AGENT
+	// and HTTP_REFERER CGI data, which errbit shows.
+	Client *Client `xml:"-"`
+
+	// Severity of the error, e.g. SeverityWarning. The notifier API has no
+	// element for it, so anything but SeverityError is sent as a param.
+	Severity string `xml:"-"`
+
+	// Time is when the error occurred.
+	Time time.Time `xml:"-"`
+
+	// Endpoint, if set, overrides the endpoint of the notifier, e.g. to send
+	// the notice to a tenant's own errbit. Filters can set it, along with
+	// ApiKey and ServerEnvironment.EnvironmentName.
+	Endpoint string `xml:"-"`
+
+	// Debug logs the payload and response of the notice, like Verbose.
+	// Filters can set it, e.g. for a class being investigated.
+	Debug bool `xml:"-"`
+
+	err         error
+	done        chan outcome
+	attachments []attachment
+
+	// deadline is that of the context of the request or call the notice
+	// was built for, if any.
+	deadline time.Time
+
+	// hungUp reports whether the client of the request the notice was built
+	// for went away, if any.
+	hungUp func() bool
+
+	// crash marks the notices of panics, and crashFile is where they were
+	// persisted, see MonitorCrash.
+	crash     bool
+	crashFile string
+}
+
+// buffers recycles the buffers the payloads are rendered into.
+var buffers = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// Severities understood by the notifier.
+const (
+	SeverityCritical = "critical"
+	SeverityError    = "error"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// Client is the client of a request: its IP, resolved through the trusted
+// proxies, user agent and referer.
+type Client struct {
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	Referer   string `json:"referer,omitempty"`
+}
+
+type notifierInfo struct {
+	Name    string `xml:"name" json:"name"`
+	Version string `xml:"version" json:"version"`
+	URL     string `xml:"url" json:"url"`
+}
+
+type errorInfo struct {
+	Class     string `xml:"class"`
+	Message   string `xml:"message"`
+	Backtrace []Line `xml:"backtrace>line"`
+}
+
+type request struct {
+	URL       string `xml:"url"`
+	Component string `xml:"component"`
+	Action    string `xml:"action"`
+	Params    vars   `xml:"params"`
+	Session   vars   `xml:"session,omitempty"`
+	CGIData   vars   `xml:"cgi-data"`
+}
+
+type serverEnvironment struct {
+	ProjectRoot     string `xml:"project-root"`
+	EnvironmentName string `xml:"environment-name"`
+	Hostname        string `xml:"hostname"`
+}
+
+// vars is rendered as a list of <var key="...">...</var> elements, sorted by key.
+type vars map[string]string
+
+type varElement struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+func (v vars) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := e.EncodeElement(varElement{k, v[k]}, xml.StartElement{Name: xml.Name{Local: "var"}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+func (v *vars) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var list struct {
+		Vars []varElement `xml:"var"`
+	}
+	if err := d.DecodeElement(&list, &start); err != nil {
+		return err
+	}
+	*v = make(vars, len(list.Vars))
+	for _, e := range list.Vars {
+		(*v)[e.Key] = e.Value
+	}
+	return nil
+}
+
+// request returns the request section of the notice, creating an empty one
+// if needed so that notices without an http request can carry extra data.
+func (n *Notice) request() *request {
+	if n.Request == nil {
+		n.Request = &request{Params: make(vars), Session: make(vars), CGIData: make(vars)}
+	}
+	return n.Request
+}
+
+// Key identifies the notices of the same error: those with the same class and
+// message, raised from the same line. It is a digest, which is stable across
+// processes of the same build, e.g. to group or deduplicate notices.
+func (n *Notice) Key() string {
+	h := fnv.New64a()
+	io.WriteString(h, n.Error.Class)
+	h.Write([]byte{0})
+	io.WriteString(h, n.Error.Message)
+	if len(n.Error.Backtrace) > 0 {
+		top := n.Error.Backtrace[0]
+		fmt.Fprintf(h, "\x00%s:%d", top.File, top.Line)
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// stamp records when the error occurred, and how long it took to report it,
+// since with asynchronous delivery the endpoint may receive it much later.
+func (n *Notice) stamp(now time.Time) {
+	if n.Time.IsZero() {
+		return
+	}
+	params := n.request().Params
+	params["occurred_at"] = n.Time.UTC().Format(time.RFC3339Nano)
+	params["reported_after"] = now.Sub(n.Time).Round(time.Millisecond).String()
+}
+
+// sanitize replaces invalid UTF-8 and strips control characters, which would
+// make the payload unparseable, from all the text of the notice.
+func (n *Notice) sanitize() {
+	n.Error.Class = sanitize(n.Error.Class)
+	n.Error.Message = sanitize(n.Error.Message)
+	if r := n.Request; r != nil {
+		r.URL = sanitize(r.URL)
+		r.Component = sanitize(r.Component)
+		r.Action = sanitize(r.Action)
+		r.Params.sanitize()
+		r.Session.sanitize()
+		r.CGIData.sanitize()
+	}
+	if c := n.Client; c != nil {
+		c.UserAgent = sanitize(c.UserAgent)
+		c.Referer = sanitize(c.Referer)
+	}
+}
+
+func (v vars) sanitize() {
+	for k, value := range v {
+		if clean := sanitize(k); clean != k {
+			delete(v, k)
+			k = clean
+		}
+		v[k] = sanitize(value)
+	}
+}
+
+// sanitize keeps tabs and newlines, but no other control characters.
+func sanitize(s string) string {
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// copy returns a copy of the notice that can be changed without changing it.
+func (n *Notice) copy() *Notice {
+	c := *n
+	c.Error.Backtrace = append([]Line(nil), n.Error.Backtrace...)
+	if n.Client != nil {
+		client := *n.Client
+		c.Client = &client
+	}
+	if n.User != nil {
+		user := *n.User
+		c.User = &user
+	}
+	if n.Request != nil {
+		r := *n.Request
+		r.Params = make(vars, len(n.Request.Params))
+		for k, v := range n.Request.Params {
+			r.Params[k] = v
+		}
+		r.Session = make(vars, len(n.Request.Session))
+		for k, v := range n.Request.Session {
+			r.Session[k] = v
+		}
+		r.CGIData = make(vars, len(n.Request.CGIData))
+		for k, v := range n.Request.CGIData {
+			r.CGIData[k] = v
+		}
+		c.Request = &r
+	}
+	return &c
+}
+
+// marshal renders the notice as an XML document.
+func (n *Notice) marshal() ([]byte, error) {
+	if n.Severity != "" && n.Severity != SeverityError {
+		n.request().Params["severity"] = n.Severity
+	}
+	if n.Client != nil {
+		n = n.withClientData()
+	}
+	buffer := buffers.Get().(*bytes.Buffer)
+	defer buffers.Put(buffer)
+	buffer.Reset()
+
+	buffer.WriteString(xml.Header)
+	encoder := xml.NewEncoder(buffer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(n); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), buffer.Bytes()...), nil
+}
+
+// withClientData returns a copy of the notice with the client in the CGI data.
+func (n *Notice) withClientData() *Notice {
+	cgi := make(vars)
+	for k, v := range n.request().CGIData {
+		cgi[k] = v
+	}
+	for k, v := range map[string]string{"REMOTE_ADDR": n.Client.IP, "HTTP_USER_AGENT": n.Client.UserAgent, "HTTP_REFERER": n.Client.Referer} {
+		if v != "" {
+			cgi[k] = v
+		}
+	}
+	r := *n.Request
+	r.CGIData = cgi
+	c := *n
+	c.Request = &r
+	return &c
+}
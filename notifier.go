@@ -0,0 +1,999 @@
+package airbrake
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Notifier reports errors to an airbrake (or errbit) endpoint. Unlike the
+// package-level functions, which share the package-level settings, each
+// Notifier has its own configuration, set with options.
+type Notifier struct {
+	apiKey               string
+	environmentKeys      map[string]string
+	endpoint             string
+	environment          string
+	verbose              bool
+	diagnosticsInterval  time.Duration
+	diagnoseFailures     bool
+	prettyParams         bool
+	rootPackage          string
+	rootPackages         []rootPackage
+	appVersion           string
+	captureGoroutines    bool
+	wrapPanics           bool
+	environmentVariables []string
+	tags                 map[string]string
+	captureMemStats      bool
+	captureKubernetes    bool
+	captureLabels        bool
+	requestIDHeader      string
+	headerAllowlist      []string
+	parseJSONBody        bool
+	trustedProxies       []*net.IPNet
+	absoluteURL          bool
+	splitErrors          bool
+	notifyClientErrors   bool
+	serverErrors         bool
+	ignoreDisconnects    bool
+	timeoutSeverity      string
+	timeoutSampleRate    float64
+	dryRun               bool
+	userAgent            string
+	scrubURL             func(url string) string
+	keepRawURL           bool
+	scrubValues          []*regexp.Regexp
+	deliveryHeaders      http.Header
+	basicAuthUser        string
+	basicAuthPassword    string
+	tlsConfig            *tls.Config
+	collapseStdlib       bool
+	classFunc            func(e error) string
+	actionFunc           func(frame Line) (component, action string)
+	traceContext         func(ctx context.Context) (traceID, spanID string)
+	client               *http.Client
+	serializer           Serializer
+	decoder              ResponseDecoder
+	sender               Sender
+	limits               Limits
+	minimalFrames        int
+	filters              []func(*Notice) *Notice
+	configs              []*Config
+	onSuccess            []func(Notice, Response)
+	onFailure            []func(Notice, error)
+	observers            []func(*Notice)
+	extractors           []func(context.Context, *Notice)
+	users                []userExtractor
+	enrichers            []Enricher
+	disabledEnrichers    []string
+	breakerThreshold     int
+	breakerCooldown      time.Duration
+	queueSize            int
+	queuePolicy          QueuePolicy
+	syncTimeout          time.Duration
+	deadlineMargin       time.Duration
+	batchSize            int
+	batchDelay           time.Duration
+	dedupWindow          time.Duration
+	crashDir             string
+	hourlyQuota          int
+	routesEndpoint       string
+	routesInterval       time.Duration
+	clock                Clock
+	rand                 Rand
+	state                *state
+}
+
+// state is the runtime state of a Notifier. The package-level
+// functions share a single one. The counters come first,
+// for 64-bit alignment of atomic operations.
+type state struct {
+	stats   stats
+	queue   queue
+	breaker breaker
+	dedup   dedup
+	routes  routes
+	recent  recent
+	quota   quota
+
+	diagnostics diagnostics
+
+	buildInfo sync.Once
+}
+
+// Option configures a Notifier.
+type Option func(*Notifier)
+
+// NewNotifier returns a Notifier for the API key. It uses the same defaults
+// as the package-level settings, unless overridden by the options.
+func NewNotifier(apiKey string, options ...Option) *Notifier {
+	n := &Notifier{
+		apiKey:            apiKey,
+		endpoint:          "https://api.airbrake.io/notifier_api/v2/notices",
+		environment:       "development",
+		requestIDHeader:   "X-Request-Id",
+		client:            http.DefaultClient,
+		serializer:        XML,
+		decoder:           DecodeResponse,
+		actionFunc:        InferAction,
+		breakerThreshold:  5,
+		breakerCooldown:   time.Minute,
+		batchSize:         20,
+		routesInterval:    defaultRoutesInterval,
+		ignoreDisconnects: true,
+		wrapPanics:        true,
+		timeoutSampleRate: 1,
+		deadlineMargin:    time.Second,
+		clock:             systemClock{},
+		rand:              globalRand{},
+		state:             new(state),
+	}
+	for _, option := range options {
+		option(n)
+	}
+	n.resolveKey()
+	return n
+}
+
+// resolveKey picks the API key configured for the environment, if any.
+func (n *Notifier) resolveKey() {
+	if key, ok := n.environmentKeys[n.environment]; ok {
+		n.apiKey = key
+	}
+}
+
+// WithEndpoint sets the URL the notices are posted to.
+func WithEndpoint(endpoint string) Option {
+	return func(n *Notifier) { n.endpoint = endpoint }
+}
+
+// WithEnvironment sets the environment name, e.g. production.
+func WithEnvironment(environment string) Option {
+	return func(n *Notifier) { n.environment = environment }
+}
+
+// WithEnvironmentKeys works like the EnvironmentKeys setting.
+func WithEnvironmentKeys(keys map[string]string) Option {
+	return func(n *Notifier) { n.environmentKeys = keys }
+}
+
+// WithVerbose enables logging of the payloads and responses.
+func WithVerbose(verbose bool) Option {
+	return func(n *Notifier) { n.verbose = verbose }
+}
+
+// WithDiagnostics works like the DiagnosticsInterval and DiagnoseFailures
+// settings.
+func WithDiagnostics(interval time.Duration, failures bool) Option {
+	return func(n *Notifier) { n.diagnosticsInterval, n.diagnoseFailures = interval, failures }
+}
+
+// WithHTTPClient sets the client used to post the notices.
+func WithHTTPClient(client *http.Client) Option {
+	return func(n *Notifier) { n.client = client }
+}
+
+// WithSerializer sets how notices are rendered, see Serializer.
+func WithSerializer(serializer Serializer) Option {
+	return func(n *Notifier) { n.serializer = serializer }
+}
+
+// WithSender works like the NoticeSender setting.
+func WithSender(sender Sender) Option {
+	return func(n *Notifier) { n.sender = sender }
+}
+
+// WithResponseDecoder sets how the answers of the endpoint are interpreted,
+// see ResponseDecoder.
+func WithResponseDecoder(decoder ResponseDecoder) Option {
+	return func(n *Notifier) { n.decoder = decoder }
+}
+
+// WithLimits works like the PayloadLimits setting.
+func WithLimits(limits Limits) Option {
+	return func(n *Notifier) { n.limits = limits }
+}
+
+// WithMinimal works like the MinimalFrames setting.
+func WithMinimal(frames int) Option {
+	return func(n *Notifier) { n.minimalFrames = frames }
+}
+
+// WithFilter registers a filter, see Notifier.AddFilter.
+func WithFilter(filter func(*Notice) *Notice) Option {
+	return func(n *Notifier) { n.AddFilter(filter) }
+}
+
+// WithEnricher registers an enricher, see Notifier.AddEnricher.
+func WithEnricher(enricher Enricher) Option {
+	return func(n *Notifier) { n.AddEnricher(enricher) }
+}
+
+// WithoutEnrichers works like the DisabledEnrichers setting.
+func WithoutEnrichers(names ...string) Option {
+	return func(n *Notifier) { n.disabledEnrichers = names }
+}
+
+// WithPrettyParams works like the PrettyParams setting.
+func WithPrettyParams(pretty bool) Option {
+	return func(n *Notifier) { n.prettyParams = pretty }
+}
+
+// WithRootPackage works like the RootPackage setting.
+func WithRootPackage(rootPackage string) Option {
+	return func(n *Notifier) { n.rootPackage = rootPackage }
+}
+
+// WithRootPackages works like the RootPackages setting.
+func WithRootPackages(roots map[string]string) Option {
+	return func(n *Notifier) { n.rootPackages = sortRoots(roots) }
+}
+
+// WithAppVersion works like the AppVersion setting.
+func WithAppVersion(version string) Option {
+	return func(n *Notifier) { n.appVersion = version }
+}
+
+// WithGoroutines works like the CaptureGoroutines setting.
+func WithGoroutines(capture bool) Option {
+	return func(n *Notifier) { n.captureGoroutines = capture }
+}
+
+// WithPanicWrapping works like the WrapPanics setting.
+func WithPanicWrapping(wrap bool) Option {
+	return func(n *Notifier) { n.wrapPanics = wrap }
+}
+
+// WithEnvironmentVariables works like the EnvironmentVariables setting.
+func WithEnvironmentVariables(names ...string) Option {
+	return func(n *Notifier) { n.environmentVariables = names }
+}
+
+// WithTags works like the Tags setting.
+func WithTags(tags map[string]string) Option {
+	return func(n *Notifier) { n.tags = tags }
+}
+
+// WithMemStats works like the CaptureMemStats setting.
+func WithMemStats(capture bool) Option {
+	return func(n *Notifier) { n.captureMemStats = capture }
+}
+
+// WithKubernetes works like the CaptureKubernetes setting.
+func WithKubernetes(capture bool) Option {
+	return func(n *Notifier) { n.captureKubernetes = capture }
+}
+
+// WithLabels works like the CaptureLabels setting.
+func WithLabels(capture bool) Option {
+	return func(n *Notifier) { n.captureLabels = capture }
+}
+
+// WithRequestIDHeader works like the RequestIDHeader setting.
+func WithRequestIDHeader(header string) Option {
+	return func(n *Notifier) { n.requestIDHeader = header }
+}
+
+// WithHeaderAllowlist works like the HeaderAllowlist setting.
+func WithHeaderAllowlist(names ...string) Option {
+	return func(n *Notifier) { n.headerAllowlist = names }
+}
+
+// WithJSONBody works like the ParseJSONBody setting.
+func WithJSONBody(parse bool) Option {
+	return func(n *Notifier) { n.parseJSONBody = parse }
+}
+
+// WithTrustedProxies works like the TrustedProxies setting.
+func WithTrustedProxies(networks ...*net.IPNet) Option {
+	return func(n *Notifier) { n.trustedProxies = networks }
+}
+
+// WithAbsoluteURL works like the AbsoluteURL setting.
+func WithAbsoluteURL(absolute bool) Option {
+	return func(n *Notifier) { n.absoluteURL = absolute }
+}
+
+// WithSplitErrors works like the SplitErrors setting.
+func WithSplitErrors(split bool) Option {
+	return func(n *Notifier) { n.splitErrors = split }
+}
+
+// WithClientErrors works like the NotifyClientErrors setting.
+func WithClientErrors(notify bool) Option {
+	return func(n *Notifier) { n.notifyClientErrors = notify }
+}
+
+// WithServerErrors works like the ServerErrors setting.
+func WithServerErrors(report bool) Option {
+	return func(n *Notifier) { n.serverErrors = report }
+}
+
+// WithIgnoreDisconnects works like the IgnoreDisconnects setting.
+func WithIgnoreDisconnects(ignore bool) Option {
+	return func(n *Notifier) { n.ignoreDisconnects = ignore }
+}
+
+// WithTimeouts works like the TimeoutSeverity and TimeoutSampleRate settings.
+func WithTimeouts(severity string, sampleRate float64) Option {
+	return func(n *Notifier) {
+		n.timeoutSeverity = severity
+		n.timeoutSampleRate = sampleRate
+	}
+}
+
+// WithDryRun works like the DryRun setting.
+func WithDryRun(dryRun bool) Option {
+	return func(n *Notifier) { n.dryRun = dryRun }
+}
+
+// WithScrubURL works like the ScrubURL and KeepRawURL settings.
+func WithScrubURL(scrub func(url string) string, keepRaw bool) Option {
+	return func(n *Notifier) {
+		n.scrubURL = scrub
+		n.keepRawURL = keepRaw
+	}
+}
+
+// WithScrubValues works like the ScrubValues setting.
+func WithScrubValues(patterns ...*regexp.Regexp) Option {
+	return func(n *Notifier) { n.scrubValues = patterns }
+}
+
+// WithUserAgent works like the UserAgent setting.
+func WithUserAgent(userAgent string) Option {
+	return func(n *Notifier) { n.userAgent = userAgent }
+}
+
+// WithDeliveryHeaders works like the DeliveryHeaders setting.
+func WithDeliveryHeaders(header http.Header) Option {
+	return func(n *Notifier) { n.deliveryHeaders = header }
+}
+
+// WithBasicAuth works like the BasicAuthUser and BasicAuthPassword settings.
+func WithBasicAuth(user, password string) Option {
+	return func(n *Notifier) { n.basicAuthUser, n.basicAuthPassword = user, password }
+}
+
+// WithTLSConfig works like the TLSConfig setting.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(n *Notifier) { n.tlsConfig = config }
+}
+
+// WithCollapseStdlib works like the CollapseStdlib setting.
+func WithCollapseStdlib(collapse bool) Option {
+	return func(n *Notifier) { n.collapseStdlib = collapse }
+}
+
+// WithClassFunc works like the ClassFunc setting.
+func WithClassFunc(classFunc func(e error) string) Option {
+	return func(n *Notifier) { n.classFunc = classFunc }
+}
+
+// WithActionFunc works like the ActionFunc setting.
+func WithActionFunc(infer func(frame Line) (component, action string)) Option {
+	return func(n *Notifier) { n.actionFunc = infer }
+}
+
+// WithClock sets the clock of the notifier, e.g. a fake one in tests.
+func WithClock(clock Clock) Option {
+	return func(n *Notifier) { n.clock = clock }
+}
+
+// WithRand sets the source of randomness of the notifier, e.g. a seeded
+// one in tests.
+func WithRand(rand Rand) Option {
+	return func(n *Notifier) { n.rand = rand }
+}
+
+// WithTraceContext works like the TraceContext setting.
+func WithTraceContext(extract func(ctx context.Context) (traceID, spanID string)) Option {
+	return func(n *Notifier) { n.traceContext = extract }
+}
+
+// WithBreaker works like the BreakerThreshold and BreakerCooldown settings.
+func WithBreaker(threshold int, cooldown time.Duration) Option {
+	return func(n *Notifier) {
+		n.breakerThreshold = threshold
+		n.breakerCooldown = cooldown
+	}
+}
+
+// WithQueueSize works like the QueueSize setting.
+func WithQueueSize(size int) Option {
+	return func(n *Notifier) { n.queueSize = size }
+}
+
+// WithQueuePolicy works like the QueueFullPolicy setting.
+func WithQueuePolicy(policy QueuePolicy) Option {
+	return func(n *Notifier) { n.queuePolicy = policy }
+}
+
+// WithDedupWindow works like the DedupWindow setting.
+func WithDedupWindow(window time.Duration) Option {
+	return func(n *Notifier) { n.dedupWindow = window }
+}
+
+// WithHourlyQuota works like the HourlyQuota setting.
+func WithHourlyQuota(quota int) Option {
+	return func(n *Notifier) { n.hourlyQuota = quota }
+}
+
+// WithRoutes works like the RoutesEndpoint and RoutesInterval settings.
+func WithRoutes(endpoint string, interval time.Duration) Option {
+	return func(n *Notifier) {
+		n.routesEndpoint = endpoint
+		n.routesInterval = interval
+	}
+}
+
+// WithSyncTimeout works like the SyncTimeout setting.
+func WithSyncTimeout(timeout time.Duration) Option {
+	return func(n *Notifier) { n.syncTimeout = timeout }
+}
+
+// WithDeadlineMargin works like the DeadlineMargin setting.
+func WithDeadlineMargin(margin time.Duration) Option {
+	return func(n *Notifier) { n.deadlineMargin = margin }
+}
+
+// WithBatch works like the BatchSize and BatchDelay settings.
+func WithBatch(size int, delay time.Duration) Option {
+	return func(n *Notifier) {
+		n.batchSize = size
+		n.batchDelay = delay
+	}
+}
+
+// AddFilter registers a filter that is run on every notice before it is sent.
+// The filter may modify the notice, or return nil to drop it.
+func (n *Notifier) AddFilter(filter func(*Notice) *Notice) {
+	n.filters = append(n.filters, filter)
+}
+
+// OnNotice registers an observer that is run on every notice that passed the
+// filters, before it is sent, whatever the outcome of the delivery. It can be
+// used to fan out errors to other sinks, but should not modify the notice.
+func (n *Notifier) OnNotice(observer func(*Notice)) {
+	n.observers = append(n.observers, observer)
+}
+
+func (n *Notifier) Error(e error, request *http.Request) error {
+	return n.send(n.newNotice(e, request, 0))
+}
+
+func (n *Notifier) Notify(e error) error {
+	return n.send(n.newNotice(e, nil, 0))
+}
+
+// NotifySkip works like the package-level NotifySkip.
+func (n *Notifier) NotifySkip(e error, skip int) error {
+	return n.send(n.newNotice(e, nil, skip))
+}
+
+// NotifyWithStack works like the package-level NotifyWithStack.
+func (n *Notifier) NotifyWithStack(e error, pcs []uintptr) error {
+	notice := n.newNotice(e, nil, 0)
+	notice.Error.Backtrace = n.locateAll(lines(pcs))
+	return n.send(notice)
+}
+
+// Notifyf works like the package-level Notifyf.
+func (n *Notifier) Notifyf(format string, args ...interface{}) error {
+	return n.send(n.newNotice(fmt.Errorf(format, args...), nil, 0))
+}
+
+// NotifyWithFields works like the package-level NotifyWithFields.
+func (n *Notifier) NotifyWithFields(e error, fields map[string]interface{}) error {
+	notice := n.newNotice(e, nil, 0)
+	addFields(notice, fields)
+	return n.send(notice)
+}
+
+// NotifyMessage works like the package-level NotifyMessage.
+func (n *Notifier) NotifyMessage(class, message string, fields map[string]interface{}) error {
+	notice := n.newNotice(errors.New(message), nil, 0)
+	notice.Error.Class = class
+	addFields(notice, fields)
+	return n.send(notice)
+}
+
+// CapturePanic works like the package-level CapturePanic.
+func (n *Notifier) CapturePanic(r *http.Request) {
+	if rec := recover(); rec != nil {
+		panic(n.reported(rec, n.capture(rec, r)))
+	}
+}
+
+// CapturePanicHandler works like the package-level CapturePanicHandler.
+func (n *Notifier) CapturePanicHandler(app http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer n.CapturePanic(r)
+		app(w, r)
+	}
+}
+
+// capture reports the recovered panic value, unless it was already reported.
+// It reports whether it sent a notice.
+func (n *Notifier) capture(rec interface{}, r *http.Request) bool {
+	if _, ok := rec.(*ReportedPanic); ok {
+		return false
+	}
+	var err error
+	if e, ok := rec.(error); ok {
+		log.Printf("Recording err %s", e)
+		err = e
+	} else if e, ok := rec.(string); ok {
+		log.Printf("Recording string %s", e)
+		err = errors.New(e)
+	} else {
+		log.Printf("Recording panic %v", rec)
+		err = errors.New(fmt.Sprint(rec))
+	}
+
+	if n.keyMissing() {
+		return false
+	}
+	notice := n.newNotice(err, r, 0)
+	notice.Error.Backtrace = trimPanic(notice.Error.Backtrace)
+	notice.crash = true
+	if n.captureGoroutines {
+		notice.request().CGIData["GOROUTINES"] = goroutines()
+	}
+	n.send(notice)
+	return true
+}
+
+// send runs the filters and posts or queues the notice.
+func (n *Notifier) send(notice *Notice) error {
+	if n.keyMissing() {
+		return apiKeyMissing
+	}
+
+	if errs := unjoin(notice.err); len(errs) > 1 && n.splitErrors {
+		var first error
+		for _, e := range errs {
+			c := notice.with(e)
+			n.classify(c, e)
+			if err := n.send(c); err != nil && first == nil {
+				first = err
+			}
+		}
+		return first
+	}
+
+	if disconnect(notice.err) {
+		if n.ignoreDisconnects && notice.clientGone() {
+			atomic.AddUint64(&n.state.stats.ignored, 1)
+			finish(notice.done, NoticeResult{}, ErrDropped)
+			return nil
+		}
+		notice.Severity = SeverityWarning
+	}
+
+	if timeout(notice.err) && n.sampleTimeout(notice) {
+		atomic.AddUint64(&n.state.stats.sampled, 1)
+		finish(notice.done, NoticeResult{}, ErrDropped)
+		return nil
+	}
+
+	done, crash := notice.done, notice.crash
+	if notice = n.filter(notice); notice == nil {
+		finish(done, NoticeResult{}, ErrDropped)
+		return nil
+	}
+
+	for _, observer := range n.observers {
+		observer(notice)
+	}
+
+	n.state.recent.record(notice, n.clock.Now())
+	if n.deduplicate(notice) {
+		atomic.AddUint64(&n.state.stats.suppressed, 1)
+		finish(done, NoticeResult{}, ErrDropped)
+		return nil
+	}
+
+	if n.overQuota() {
+		finish(done, NoticeResult{}, ErrDropped)
+		return nil
+	}
+
+	if crash && !n.dryRun {
+		n.persist(notice)
+	}
+	return n.dispatch(notice)
+}
+
+// dispatch delivers the notice, depending on the delivery mode.
+func (n *Notifier) dispatch(notice *Notice) error {
+	if n.dryRun {
+		return n.dryDeliver(notice)
+	}
+	if n.doomed(notice) {
+		n.divert(notice)
+		return nil
+	}
+	if n.queueSize > 0 && n.syncTimeout > 0 {
+		return n.deliverOrEnqueue(notice)
+	}
+	if n.queueSize > 0 {
+		n.enqueue(notice)
+		return nil
+	}
+	return n.deliver(notice)
+}
+
+// filter runs the enrichers, infers the action and runs the filters, then
+// cleans up and truncates the notice. It returns nil if a filter dropped the notice.
+func (n *Notifier) filter(notice *Notice) *Notice {
+	n.enrich(notice)
+	n.inferAction(notice)
+	for _, filter := range n.filters {
+		if notice = filter(notice); notice == nil {
+			return nil
+		}
+	}
+	if n.minimalFrames > 0 {
+		notice.minimize(n.minimalFrames)
+	}
+	notice.scrubValues(n.scrubValues)
+	notice.sanitize()
+	notice.truncate(n.limits)
+	notice.attach(n.limits)
+	return notice
+}
+
+// deliver posts the notice, unless the circuit breaker is open.
+func (n *Notifier) deliver(notice *Notice) error {
+	if !n.state.breaker.allow(n.breakerThreshold, n.breakerCooldown, n.clock.Now()) {
+		atomic.AddUint64(&n.state.stats.shortCircuited, 1)
+		n.failed(notice, ErrCircuitOpen)
+		return ErrCircuitOpen
+	}
+	response, err := n.attempt(context.Background(), notice)
+	return n.settle(notice, response, err)
+}
+
+// attempt posts the notice, recording the latency and outcome.
+func (n *Notifier) attempt(ctx context.Context, notice *Notice) (Response, error) {
+	start := n.clock.Now()
+	response, err := n.sendWith(ctx, notice)
+	n.state.stats.observe(n.clock.Now().Sub(start))
+	n.state.breaker.record(err, n.breakerThreshold, n.clock.Now())
+	return response, err
+}
+
+// settle runs the callbacks for the outcome of the delivery.
+func (n *Notifier) settle(notice *Notice, response Response, err error) error {
+	if err != nil {
+		atomic.AddUint64(&n.state.stats.failed, 1)
+		if notice.crashFile != "" && !transient(response) {
+			// Rejected for good, it would never be delivered.
+			os.Remove(notice.crashFile)
+		}
+		n.failed(notice, err)
+		return err
+	}
+
+	atomic.AddUint64(&n.state.stats.sent, 1)
+	if notice.crashFile != "" {
+		os.Remove(notice.crashFile)
+	}
+	for _, callback := range n.onSuccess {
+		callback(*notice, response)
+	}
+	finish(notice.done, NoticeResult{Response: response}, nil)
+	return nil
+}
+
+// deliverOrEnqueue posts the notice synchronously, giving up after the sync
+// timeout, and queues it if the delivery failed for a reason that may be
+// transient: the endpoint timed out, could not be reached, was overloaded
+// or had an internal error. Notices rejected for good are not queued.
+func (n *Notifier) deliverOrEnqueue(notice *Notice) error {
+	if !n.state.breaker.allow(n.breakerThreshold, n.breakerCooldown, n.clock.Now()) {
+		n.enqueue(notice)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), n.syncTimeout)
+	response, err := n.attempt(ctx, notice)
+	cancel()
+	if err != nil && transient(response) {
+		n.enqueue(notice)
+		return nil
+	}
+	return n.settle(notice, response, err)
+}
+
+// transient reports whether a delivery failed for a reason that may be
+// transient, judging by its response, if any.
+func transient(response Response) bool {
+	status := response.StatusCode
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
+
+// dryDeliver serializes the notice, but does not post it.
+func (n *Notifier) dryDeliver(notice *Notice) error {
+	notice.stamp(n.clock.Now())
+	payload, err := n.serialize(notice)
+	if err != nil {
+		log.Printf("Airbrake error: %s", err)
+		finish(notice.done, NoticeResult{DryRun: true}, err)
+		return err
+	}
+	if n.diagnose(notice) {
+		log.Printf("Airbrake dry run payload for endpoint %s: %s", n.endpointFor(notice), payload)
+	}
+	atomic.AddUint64(&n.state.stats.dryRun, 1)
+	finish(notice.done, NoticeResult{DryRun: true}, nil)
+	return nil
+}
+
+// failed runs the delivery failure callbacks.
+func (n *Notifier) failed(notice *Notice, err error) {
+	for _, callback := range n.onFailure {
+		callback(*notice, err)
+	}
+	finish(notice.done, NoticeResult{}, err)
+}
+
+// allowHeader checks the header against the allowlist, if any.
+func (n *Notifier) allowHeader(name string) bool {
+	if len(n.headerAllowlist) == 0 {
+		return true
+	}
+	for _, allowed := range n.headerAllowlist {
+		if strings.EqualFold(name, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// locateAll locates the files of the backtrace, and marks its dependencies.
+func (n *Notifier) locateAll(lines []Line) []Line {
+	for i := range lines {
+		line := &lines[i]
+		_, line.Dependency = dependency(line.File)
+		line.File = n.locate(line.File)
+	}
+	return lines
+}
+
+func (n *Notifier) locate(f string) string {
+	if short, ok := dependency(f); ok {
+		return short
+	}
+	for _, root := range n.rootPackages {
+		if parts := strings.Split(f, root.path); len(parts) == 2 {
+			return "[PROJECT_ROOT]" + root.dir + parts[1]
+		}
+	}
+	root := n.root()
+	if root == "" {
+		return f
+	}
+	parts := strings.Split(f, root)
+	if len(parts) == 2 {
+		return "[PROJECT_ROOT]" + parts[1]
+	} else {
+		return f
+	}
+}
+
+// endpointFor returns the endpoint the notice is posted to.
+func (n *Notifier) endpointFor(notice *Notice) string {
+	if notice.Endpoint != "" {
+		return notice.Endpoint
+	}
+	return n.endpoint
+}
+
+func (n *Notifier) post(ctx context.Context, notice *Notice) (Response, error) {
+	notice.stamp(n.clock.Now())
+	payload, err := n.serialize(notice)
+	if err != nil {
+		log.Printf("Airbrake error: %s", err)
+		return Response{}, err
+	}
+
+	logged := n.diagnose(notice)
+	if logged {
+		log.Printf("Airbrake payload for endpoint %s: %s", n.endpointFor(notice), payload)
+	}
+
+	request, err := http.NewRequestWithContext(delivery(ctx), "POST", n.endpointFor(notice), bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("Airbrake error: %s", err)
+		return Response{}, err
+	}
+	for k, v := range n.deliveryHeaders {
+		request.Header[k] = v
+	}
+	if n.userAgent != "" {
+		request.Header.Set("User-Agent", n.userAgent)
+	}
+	request.Header.Set("Content-Type", n.serializer.ContentType())
+	n.setBasicAuth(request)
+	// The version 3 payload has no key, unlike the XML document.
+	if _, ok := n.serializer.(jsonSerializer); ok && notice.ApiKey != "" && request.Header.Get("Authorization") == "" {
+		request.Header.Set("Authorization", "Bearer "+notice.ApiKey)
+	}
+
+	response, err := n.httpClient().Do(request)
+	if err != nil {
+		log.Printf("Airbrake error: %s", err)
+		n.diagnoseFailure(notice, payload, logged, err)
+		return Response{}, err
+	}
+
+	defer response.Body.Close()
+
+	body, _ := ioutil.ReadAll(io.LimitReader(response.Body, maxResponseSize))
+	if logged {
+		log.Printf("response: %s", body)
+	}
+	// Drain what is left, so the connection can be reused.
+	io.Copy(ioutil.Discard, io.LimitReader(response.Body, maxDrainSize))
+
+	if logged {
+		log.Printf("Airbrake post: %s status code: %d", notice.Error.Message, response.StatusCode)
+	}
+
+	decoded, err := n.decoder(response, body)
+	if err != nil {
+		n.diagnoseFailure(notice, payload, logged, fmt.Errorf("%s: %s", response.Status, body))
+	}
+	return decoded, err
+}
+
+// newNotice compiles the notice for the error. skip is the number
+// of frames to omit above the caller of the exported entry point.
+func (n *Notifier) newNotice(e error, request *http.Request, skip int) *Notice {
+	if e == nil {
+		e = nilError{}
+	}
+	severity, e := severityOf(e)
+	notice := &Notice{
+		Version:  "2.0",
+		ApiKey:   n.apiKey,
+		Notifier: notifierInfo{"Airbrake Golang", "0.0.1", "http://airbrake.io"},
+		Error: errorInfo{
+			Message: e.Error(),
+		},
+		ServerEnvironment: serverEnvironment{EnvironmentName: n.environment},
+		Severity:          SeverityError,
+		Time:              n.clock.Now(),
+		err:               e,
+	}
+
+	notice.Error.Backtrace = n.locateAll(stacktrace(3+skip, n.collapseStdlib))
+
+	for _, name := range n.environmentVariables {
+		if value := os.Getenv(name); !omit(name, []string{value}) {
+			notice.request().CGIData[name] = value
+		}
+	}
+	addTags(notice, n.tags)
+
+	if n.captureMemStats {
+		addMemStats(notice)
+	}
+
+	if n.captureKubernetes {
+		for k, v := range kubernetes() {
+			notice.request().CGIData[k] = v
+		}
+	}
+
+	if errs := unjoin(e); len(errs) > 1 && !n.splitErrors {
+		addErrors(notice, errs, n.className)
+	}
+
+	n.classify(notice, e)
+	if severity != "" {
+		notice.Severity = severity
+	}
+
+	if request == nil {
+		return notice
+	}
+	notice.deadline, _ = request.Context().Deadline()
+	notice.hungUp = func() bool { return request.Context().Err() == context.Canceled }
+	n.addDeadline(request.Context(), notice)
+	n.extract(request.Context(), notice)
+	n.extractUser(request, notice)
+	if parseForm(request) != nil {
+		return notice
+	}
+
+	// Compile relevant request parameters.
+	req := notice.request()
+	// Nested http Muxes muck with the URL, prefer RequestURI.
+	if request.RequestURI != "" {
+		req.URL = request.RequestURI
+	} else {
+		req.URL = request.URL.String()
+	}
+	if n.absoluteURL {
+		req.URL = externalURL(request, req.URL, n.trusted)
+	}
+	if n.scrubURL != nil {
+		if raw := req.URL; n.keepRawURL {
+			req.Params["raw_url"] = raw
+		}
+		req.URL = n.scrubURL(req.URL)
+	}
+
+	// Compile header parameters.
+	header := req.CGIData
+	header["REQUEST_METHOD"] = request.Method
+	header["REQUEST_PROTOCOL"] = request.Proto
+	for k, v := range request.Header {
+		if k == "User-Agent" || k == "Referer" {
+			// Part of the client, below.
+			continue
+		}
+		if !omit(k, v) && n.allowHeader(k) {
+			// errbit processes some entries, e.g. user agent, and expects
+			// the keys to be uppercased, underscored and prefixed with HTTP_
+			k := strings.ToUpper(strings.Replace(k, "-", "_", -1))
+			header["HTTP_"+k] = v[0]
+		}
+	}
+	client := Client{IP: clientIP(request, n.trusted)}
+	if n.allowHeader("User-Agent") {
+		client.UserAgent = request.UserAgent()
+	}
+	if n.allowHeader("Referer") {
+		client.Referer = request.Referer()
+	}
+	if client != (Client{}) {
+		notice.Client = &client
+	}
+
+	// Compile query/form parameters.
+	form := req.Params
+	for k, v := range request.Form {
+		for k, v := range formValues(k, v) {
+			form[k] = v
+			if n.prettyParams {
+				header["?"+k] = v
+			}
+		}
+	}
+	// Promote the correlation ID, so the notice can be joined against logs.
+	if n.requestIDHeader != "" {
+		if id := request.Header.Get(n.requestIDHeader); id != "" {
+			form["request_id"] = id
+		}
+	}
+	for k, v := range fileParams(request) {
+		form[k] = v
+	}
+	if n.parseJSONBody {
+		for k, v := range jsonParams(request) {
+			form[k] = v
+			if n.prettyParams {
+				header["?"+k] = v
+			}
+		}
+	}
+
+	return notice
+}
@@ -0,0 +1,308 @@
+package airbrake
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// capturing returns an option capturing the notices into reported,
+// and dropping them.
+func capturing(reported *[]*Notice) Option {
+	return WithFilter(func(notice *Notice) *Notice {
+		*reported = append(*reported, notice)
+		return nil
+	})
+}
+
+func TestNotifierFilter(t *testing.T) {
+	var received []Notice
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var notice Notice
+		if err := xml.Unmarshal(body, &notice); err != nil {
+			t.Error(err)
+		}
+		received = append(received, notice)
+	}))
+	defer server.Close()
+
+	n := NewNotifier("key",
+		WithEndpoint(server.URL),
+		WithEnvironment("test"),
+		WithFilter(func(notice *Notice) *Notice {
+			if notice.Error.Message == "ignored" {
+				return nil
+			}
+			notice.Error.Class = "Filtered"
+			return notice
+		}),
+	)
+
+	if err := n.Notify(errors.New("ignored")); err != nil {
+		t.Error(err)
+	}
+	if err := n.Notify(errors.New("Boom!")); err != nil {
+		t.Error(err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("expected one notice, got: %d", len(received))
+	}
+	notice := received[0]
+	if notice.ApiKey != "key" || notice.Error.Class != "Filtered" || notice.ServerEnvironment.EnvironmentName != "test" {
+		t.Errorf("unexpected notice: %+v", notice)
+	}
+}
+
+func TestNotifyMessage(t *testing.T) {
+	var reported []*Notice
+	n := NewNotifier("key", capturing(&reported))
+	n.NotifyMessage("QuotaExceeded", "Over the quota", map[string]interface{}{"account": 42, "password": "sesame"})
+
+	if len(reported) != 1 {
+		t.Fatalf("expected 1 notice, got: %d", len(reported))
+	}
+	notice := reported[0]
+	if notice.Error.Class != "QuotaExceeded" || notice.Error.Message != "Over the quota" {
+		t.Errorf("unexpected error: %+v", notice.Error)
+	}
+	if params := notice.Request.Params; params["account"] != "42" || params["password"] != "" {
+		t.Errorf("unexpected params: %v", params)
+	}
+}
+
+func TestNotifierApiKeyMissing(t *testing.T) {
+	if err := NewNotifier("").Notify(errors.New("Boom!")); err != apiKeyMissing {
+		t.Errorf("expected apiKeyMissing, got: %v", err)
+	}
+}
+
+func TestDeliveryCallbacks(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`<notice><id>42</id><url>http://errbit.example.com/locate/42</url></notice>`))
+	}))
+	defer server.Close()
+
+	var urls []string
+	var failures []error
+	n := NewNotifier("key", WithEndpoint(server.URL))
+	n.OnDeliverySuccess(func(notice Notice, response Response) {
+		urls = append(urls, response.URL)
+	})
+	n.OnDeliveryFailure(func(notice Notice, err error) {
+		failures = append(failures, err)
+	})
+
+	n.Notify(errors.New("Boom!"))
+	status = http.StatusUnprocessableEntity
+	n.Notify(errors.New("Boom!"))
+
+	if len(urls) != 1 || urls[0] != "http://errbit.example.com/locate/42" {
+		t.Errorf("unexpected urls: %v", urls)
+	}
+	if len(failures) != 1 || failures[0] != badResponse {
+		t.Errorf("unexpected failures: %v", failures)
+	}
+}
+
+func TestDecodeResponse(t *testing.T) {
+	response := &http.Response{StatusCode: http.StatusUnprocessableEntity}
+	decoded, err := DecodeResponse(response, []byte(`<errors><error>Api key is invalid</error></errors>`))
+	if err != badResponse || len(decoded.Errors) != 1 || decoded.Errors[0] != "Api key is invalid" {
+		t.Errorf("unexpected response: %+v, %v", decoded, err)
+	}
+}
+
+func TestResponseDecoder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors": ["quota exceeded"]}`))
+	}))
+	defer server.Close()
+
+	errQuota := errors.New("quota exceeded")
+	var failures []error
+	n := NewNotifier("key", WithEndpoint(server.URL), WithResponseDecoder(func(response *http.Response, body []byte) (Response, error) {
+		r, err := DecodeResponse(response, body)
+		if err == nil && bytes.Contains(body, []byte("quota exceeded")) {
+			err = errQuota
+		}
+		return r, err
+	}))
+	n.OnDeliverySuccess(func(notice Notice, response Response) {
+		t.Errorf("unexpected success: %+v", response)
+	})
+	n.OnDeliveryFailure(func(notice Notice, err error) {
+		failures = append(failures, err)
+	})
+
+	if err := n.Notify(errors.New("Boom!")); err != errQuota {
+		t.Errorf("expected errQuota, got: %v", err)
+	}
+	if len(failures) != 1 || failures[0] != errQuota {
+		t.Errorf("unexpected failures: %v", failures)
+	}
+}
+
+func TestObservers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var observed []string
+	n := NewNotifier("key", WithEndpoint(server.URL), WithFilter(func(notice *Notice) *Notice {
+		if notice.Error.Message == "Ignored" {
+			return nil
+		}
+		return notice
+	}))
+	n.OnNotice(func(notice *Notice) {
+		observed = append(observed, notice.Error.Message)
+	})
+
+	n.Notify(errors.New("Boom!"))
+	n.Notify(errors.New("Ignored"))
+
+	if len(observed) != 1 || observed[0] != "Boom!" {
+		t.Errorf("unexpected notices: %v", observed)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	n := NewNotifier("key", WithEndpoint(server.URL), WithDryRun(true), WithQueueSize(10))
+	if err := n.Notify(errors.New("Boom!")); err != nil {
+		t.Fatal(err)
+	}
+
+	if requests != 0 {
+		t.Errorf("unexpected requests: %d", requests)
+	}
+	if stats := n.Stats(); stats.DryRun != 1 || stats.Sent != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestEnvironmentKeys(t *testing.T) {
+	keys := map[string]string{"production": "prod-key", "staging": "staging-key"}
+	for environment, key := range map[string]string{"production": "prod-key", "staging": "staging-key", "development": "default-key"} {
+		n := NewNotifier("default-key", WithEnvironmentKeys(keys), WithEnvironment(environment))
+		if notice := n.newNotice(errors.New("Boom!"), nil, 0); notice.ApiKey != key {
+			t.Errorf("%s expected: %s got: %s", environment, key, notice.ApiKey)
+		}
+	}
+}
+
+func TestOccurrenceTime(t *testing.T) {
+	notice := NewNotifier("key").newNotice(errors.New("Boom!"), nil, 0)
+	notice.Time = time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC)
+	notice.stamp(notice.Time.Add(90 * time.Second))
+
+	if notice.Request.Params["occurred_at"] != "2015-01-02T03:04:05Z" || notice.Request.Params["reported_after"] != "1m30s" {
+		t.Errorf("unexpected params: %v", notice.Request.Params)
+	}
+}
+
+func TestConnectionReuse(t *testing.T) {
+	var connections int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write(bytes.Repeat([]byte("x"), 2*maxResponseSize))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	n := NewNotifier("key", WithEndpoint(server.URL))
+	for i := 0; i < 3; i++ {
+		response, err := n.post(context.Background(), n.newNotice(errors.New("Boom!"), nil, 0))
+		if err != badResponse || len(response.Body) != maxResponseSize {
+			t.Fatalf("unexpected response: %d bytes, %v", len(response.Body), err)
+		}
+	}
+
+	if connections := atomic.LoadInt32(&connections); connections != 1 {
+		t.Errorf("expected a single connection, got: %d", connections)
+	}
+}
+
+func TestDeliveryHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer server.Close()
+
+	n := NewNotifier("key", WithEndpoint(server.URL), WithUserAgent("billing/1.2"),
+		WithDeliveryHeaders(http.Header{"X-Proxy-Token": {"secret"}}))
+	if err := n.Notify(errors.New("Boom!")); err != nil {
+		t.Fatal(err)
+	}
+
+	if received.Get("User-Agent") != "billing/1.2" || received.Get("X-Proxy-Token") != "secret" {
+		t.Errorf("unexpected headers: %v", received)
+	}
+}
+
+func TestJSONAuthorization(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer server.Close()
+
+	n := NewNotifier("key", WithEndpoint(server.URL), WithSerializer(JSON), WithFilter(func(notice *Notice) *Notice {
+		notice.ApiKey = "tenant-key"
+		return notice
+	}))
+	if err := n.Notify(errors.New("Boom!")); err != nil {
+		t.Fatal(err)
+	}
+
+	if received.Get("Authorization") != "Bearer tenant-key" {
+		t.Errorf("unexpected headers: %v", received)
+	}
+}
+
+func TestNoticeOverrides(t *testing.T) {
+	var received Notice
+	tenant := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		xml.Unmarshal(body, &received)
+	}))
+	defer tenant.Close()
+
+	n := NewNotifier("key", WithEndpoint("http://airbrake.invalid/"), WithFilter(func(notice *Notice) *Notice {
+		notice.Endpoint = tenant.URL
+		notice.ApiKey = "tenant-key"
+		notice.ServerEnvironment.EnvironmentName = "tenant-production"
+		return notice
+	}))
+	if err := n.Notify(errors.New("Boom!")); err != nil {
+		t.Fatal(err)
+	}
+
+	if received.ApiKey != "tenant-key" || received.ServerEnvironment.EnvironmentName != "tenant-production" {
+		t.Errorf("unexpected notice: %+v", received)
+	}
+}
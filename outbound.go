@@ -0,0 +1,126 @@
+package airbrake
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OutboundError is reported for a failed outbound request: either the
+// request failed, in which case Err is set, or the response had a status
+// code reported by the RoundTripper, 5xx by default.
+type OutboundError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+func (e *OutboundError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s %s: %s (%s)", e.Method, e.URL, e.Err, e.Duration.Round(time.Millisecond))
+	}
+	return fmt.Sprintf("%s %s: %d %s (%s)", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode),
+		e.Duration.Round(time.Millisecond))
+}
+
+func (e *OutboundError) Unwrap() error { return e.Err }
+
+// RoundTripperOption configures a RoundTripper.
+type RoundTripperOption func(*outboundTripper)
+
+// ReportStatus sets which response status codes are reported,
+// instead of the 5xx ones.
+func ReportStatus(report func(status int) bool) RoundTripperOption {
+	return func(t *outboundTripper) { t.report = report }
+}
+
+// RoundTripper returns a RoundTripper which reports the outbound requests
+// that failed, or whose response is a server error, to airbrake, e.g. to see
+// the failures of the dependencies of the application in the same dashboard.
+// The notices have the method, URL, status code and duration of the request
+// as params, and its headers, without credentials, as CGI data.
+//
+// Example:
+//
+//	client := &http.Client{Transport: airbrake.RoundTripper(http.DefaultTransport)}
+func RoundTripper(next http.RoundTripper, options ...RoundTripperOption) http.RoundTripper {
+	return newOutboundTripper(defaultNotifier, next, options)
+}
+
+// RoundTripper works like the package-level RoundTripper.
+func (n *Notifier) RoundTripper(next http.RoundTripper, options ...RoundTripperOption) http.RoundTripper {
+	return newOutboundTripper(func() *Notifier { return n }, next, options)
+}
+
+func newOutboundTripper(notifier func() *Notifier, next http.RoundTripper, options []RoundTripperOption) *outboundTripper {
+	t := &outboundTripper{notifier, next, func(status int) bool { return status >= 500 }}
+	for _, option := range options {
+		option(t)
+	}
+	return t
+}
+
+type outboundTripper struct {
+	notifier func() *Notifier
+	next     http.RoundTripper
+	report   func(status int) bool
+}
+
+func (t *outboundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := time.Now()
+	response, err := t.next.RoundTrip(r)
+	if delivering(r.Context()) {
+		return response, err
+	}
+	if err != nil {
+		t.notify(r, &OutboundError{r.Method, r.URL.Redacted(), 0, time.Since(start), err})
+	} else if t.report(response.StatusCode) {
+		t.notify(r, &OutboundError{r.Method, r.URL.Redacted(), response.StatusCode, time.Since(start), nil})
+	}
+	return response, err
+}
+
+func (t *outboundTripper) notify(r *http.Request, e *OutboundError) {
+	n := t.notifier()
+	notice := n.newNotice(e, nil, 1)
+	n.extract(r.Context(), notice)
+	req := notice.request()
+	req.URL = e.URL
+	req.Params["method"] = e.Method
+	req.Params["duration"] = e.Duration.String()
+	if e.StatusCode != 0 {
+		req.Params["status"] = fmt.Sprint(e.StatusCode)
+	}
+	for k, v := range r.Header {
+		if !omit(k, v) && !credentials(k) {
+			req.CGIData["HTTP_"+strings.ToUpper(strings.Replace(k, "-", "_", -1))] = v[0]
+		}
+	}
+	n.send(notice)
+}
+
+// credentials reports whether the header carries credentials, which omit
+// doesn't catch by name.
+func credentials(header string) bool {
+	switch http.CanonicalHeaderKey(header) {
+	case "Authorization", "Proxy-Authorization", "Cookie":
+		return true
+	}
+	return false
+}
+
+// deliveryKey marks the context of the requests posting to airbrake, so that
+// a RoundTripper used by the notifier's own client doesn't report them.
+type deliveryKey struct{}
+
+func delivery(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deliveryKey{}, true)
+}
+
+func delivering(ctx context.Context) bool {
+	return ctx.Value(deliveryKey{}) != nil
+}
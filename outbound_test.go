@@ -0,0 +1,68 @@
+package airbrake
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRoundTripper(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer upstream.Close()
+
+	var reported []*Notice
+	n := NewNotifier("key", capturing(&reported))
+	client := &http.Client{Transport: n.RoundTripper(http.DefaultTransport)}
+
+	for _, path := range []string{"/up", "/down"} {
+		request, _ := http.NewRequest("GET", upstream.URL+path+"?page=2", nil)
+		request.Header.Set("Authorization", "Bearer s3cret")
+		request.Header.Set("Accept", "application/json")
+		response, err := client.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	}
+	if _, err := client.Get("http://127.0.0.1:0/"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if len(reported) != 2 {
+		t.Fatalf("expected 2 notices, got %d", len(reported))
+	}
+	r := reported[0].Request
+	if r.URL != upstream.URL+"/down?page=2" || r.Params["method"] != "GET" || r.Params["status"] != "502" ||
+		r.CGIData["HTTP_ACCEPT"] != "application/json" || r.CGIData["HTTP_AUTHORIZATION"] != "" {
+		t.Errorf("unexpected request: %+v", r)
+	}
+	if !strings.Contains(reported[0].Error.Message, "502 Bad Gateway") {
+		t.Errorf("unexpected message: %s", reported[0].Error.Message)
+	}
+	var outbound *OutboundError
+	if !errors.As(reported[1].err, &outbound) || outbound.Err == nil || reported[1].Request.Params["status"] != "" {
+		t.Errorf("unexpected notice: %+v", reported[1])
+	}
+}
+
+func TestRoundTripperDelivery(t *testing.T) {
+	var posts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	n := NewNotifier("key", WithEndpoint(server.URL))
+	WithHTTPClient(&http.Client{Transport: n.RoundTripper(http.DefaultTransport)})(n)
+	n.Notify(errors.New("Boom!"))
+	if posts != 1 {
+		t.Errorf("expected the delivery not to be reported, got %d posts", posts)
+	}
+}
@@ -0,0 +1,31 @@
+package airbrake
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ReportedPanic is the value re-panicked by CapturePanic and NotifyOnExit,
+// with the WrapPanics setting, once they have reported the panic, so that
+// the outer recovery layers, e.g. RecoveryHandler, don't report it again.
+// Value is the recovered value, and errors.As and errors.Is see through it.
+type ReportedPanic struct {
+	Value interface{}
+}
+
+func (p *ReportedPanic) Error() string { return fmt.Sprint(p.Value) }
+
+// Unwrap returns the recovered value if it is an error, or else nil.
+func (p *ReportedPanic) Unwrap() error {
+	err, _ := p.Value.(error)
+	return err
+}
+
+// reported returns the value to re-panic with, once the recovered value has
+// been captured: it is only wrapped if a notice was sent.
+func (n *Notifier) reported(rec interface{}, sent bool) interface{} {
+	if _, ok := rec.(*ReportedPanic); ok || !sent || !n.wrapPanics || rec == http.ErrAbortHandler {
+		return rec
+	}
+	return &ReportedPanic{rec}
+}
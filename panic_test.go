@@ -0,0 +1,104 @@
+package airbrake
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReportedPanic(t *testing.T) {
+	var reported []string
+	capture := WithFilter(func(notice *Notice) *Notice {
+		reported = append(reported, notice.Error.Message)
+		return nil
+	})
+	boom := errors.New("Boom!")
+	n := NewNotifier("key", capture)
+	app := n.CapturePanicHandler(func(w http.ResponseWriter, r *http.Request) {
+		panic(boom)
+	})
+
+	w := httptest.NewRecorder()
+	n.RecoveryHandler(app, nil).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	if len(reported) != 1 {
+		t.Errorf("expected the panic to be reported once, got: %v", reported)
+	}
+
+	func() {
+		defer func() {
+			rec := recover()
+			p, ok := rec.(*ReportedPanic)
+			if !ok || p.Value != boom || !errors.Is(p, boom) {
+				t.Errorf("unexpected panic: %#v", rec)
+			}
+		}()
+		defer n.CapturePanic(nil)
+		defer n.CapturePanic(nil)
+		panic(boom)
+	}()
+	if len(reported) != 2 {
+		t.Errorf("expected the panic to be reported once, got: %v", reported)
+	}
+
+	func() {
+		defer func() {
+			if rec := recover(); rec != boom {
+				t.Errorf("unexpected panic: %#v", rec)
+			}
+		}()
+		defer NewNotifier("key", capture, WithPanicWrapping(false)).CapturePanic(nil)
+		panic(boom)
+	}()
+}
+
+func TestReportedPanicErrorLog(t *testing.T) {
+	var reported []*Notice
+	n := NewNotifier("key", capturing(&reported))
+	server := httptest.NewUnstartedServer(n.CapturePanicHandler(func(w http.ResponseWriter, r *http.Request) {
+		panic(errors.New("Boom!"))
+	}))
+	server.Config.ErrorLog = n.ErrorLog()
+	server.Start()
+	defer server.Close()
+
+	if _, err := http.Get(server.URL); err == nil {
+		t.Fatal("expected the connection to be closed by the panic")
+	}
+	server.Close()
+
+	if len(reported) != 1 || reported[0].Error.Message != "Boom!" {
+		t.Errorf("expected the panic to be reported once, got: %d", len(reported))
+	}
+}
+
+func TestReportedPanicUnsent(t *testing.T) {
+	var reported []string
+	n := NewNotifier("key", WithFilter(func(notice *Notice) *Notice {
+		reported = append(reported, notice.Error.Message)
+		return nil
+	}))
+	for _, sample := range []struct {
+		notifier *Notifier
+		wrapped  bool
+	}{
+		{n, true},
+		{NewNotifier(""), false},
+	} {
+		func() {
+			defer func() {
+				if _, ok := recover().(*ReportedPanic); ok != sample.wrapped {
+					t.Errorf("expected the panic to be wrapped: %v", sample.wrapped)
+				}
+			}()
+			defer sample.notifier.CapturePanic(nil)
+			panic(42)
+		}()
+	}
+	if len(reported) != 1 || reported[0] != "42" {
+		t.Errorf("unexpected notices: %v", reported)
+	}
+}
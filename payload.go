@@ -0,0 +1,25 @@
+package airbrake
+
+import "net/http"
+
+// BuildPayload returns the payload the package-level functions would send
+// for the error. See Notifier.BuildPayload.
+func BuildPayload(e error, request *http.Request) ([]byte, error) {
+	n := defaultNotifier()
+	return n.buildPayload(n.newNotice(e, request, 0))
+}
+
+// BuildPayload returns the exact payload that would be posted for the error,
+// after the filters, without sending it, e.g. to inspect why the endpoint
+// rejects it. It returns a nil payload if a filter dropped the notice.
+func (n *Notifier) BuildPayload(e error, request *http.Request) ([]byte, error) {
+	return n.buildPayload(n.newNotice(e, request, 0))
+}
+
+func (n *Notifier) buildPayload(notice *Notice) ([]byte, error) {
+	if notice = n.filter(notice); notice == nil {
+		return nil, nil
+	}
+	notice.stamp(n.clock.Now())
+	return n.serialize(notice)
+}
@@ -0,0 +1,33 @@
+package airbrake
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBuildPayload(t *testing.T) {
+	n := NewNotifier("key", WithFilter(func(notice *Notice) *Notice {
+		if notice.Error.Message == "Ignored" {
+			return nil
+		}
+		notice.Error.Message = "Filtered"
+		return notice
+	}))
+
+	request := httptest.NewRequest("GET", "/users?id=1", nil)
+	payload, err := n.BuildPayload(errors.New("Boom!"), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"<message>Filtered</message>", `<var key="id">1</var>`, "<api-key>key</api-key>"} {
+		if !bytes.Contains(payload, []byte(expected)) {
+			t.Errorf("%s not found in payload: %s", expected, payload)
+		}
+	}
+
+	if payload, err := n.BuildPayload(errors.New("Ignored"), nil); payload != nil || err != nil {
+		t.Errorf("unexpected payload: %s %v", payload, err)
+	}
+}
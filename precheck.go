@@ -0,0 +1,40 @@
+package airbrake
+
+// ShouldReport reports whether a notice of the class could be sent by the
+// package-level functions. See Notifier.ShouldReport.
+func ShouldReport(class string) bool {
+	return defaultNotifier().ShouldReport(class)
+}
+
+// ShouldReport reports whether a notice of the class could be sent, without
+// building it, so that hot code paths can skip formatting an error that would
+// be dropped anyway:
+//
+//	if airbrake.ShouldReport("*net.OpError") {
+//	    airbrake.Notify(fmt.Errorf("dialing %s: %w", describe(peer), err))
+//	}
+//
+// It returns false if the API key is missing (without a Sender), the class is ignored by the
+// configuration, the sample rate of the configuration is 0, the hourly quota
+// is exhausted, or the circuit breaker is open and notices are delivered
+// synchronously. The filters, and sampling at rates above 0, can still drop
+// the notice: it is not sampled here, so that it is not sampled twice. The
+// state of the notifier is left untouched.
+func (n *Notifier) ShouldReport(class string) bool {
+	if n.keyMissing() {
+		return false
+	}
+	for _, config := range n.configs {
+		if config.ignores(class) {
+			return false
+		}
+	}
+	now := n.clock.Now()
+	if n.hourlyQuota > 0 && n.state.quota.exhausted(n.hourlyQuota, now) {
+		return false
+	}
+	if !n.dryRun && n.queueSize == 0 && n.state.breaker.open(n.breakerThreshold, n.breakerCooldown, now) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package airbrake
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestShouldReport(t *testing.T) {
+	if NewNotifier("").ShouldReport("Boom") {
+		t.Error("expected no report without an API key")
+	}
+
+	rate := 0.0
+	config := &Config{IgnoreClasses: []string{"Ignored"}}
+	n := NewNotifier("key", config.Options()...)
+	if n.ShouldReport("Ignored") || !n.ShouldReport("Boom") {
+		t.Error("expected only the ignored class to be skipped")
+	}
+	config = &Config{SampleRate: &rate}
+	if NewNotifier("key", config.Options()...).ShouldReport("Boom") {
+		t.Error("expected no report at a sample rate of 0")
+	}
+
+	clock := &fakeClock{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	n = NewNotifier("key", WithDryRun(true), WithClock(clock), WithHourlyQuota(2))
+	for i := 0; i < 2; i++ {
+		if !n.ShouldReport("Boom") {
+			t.Errorf("expected a report within the quota, notice %d", i)
+		}
+		n.NotifyMessage("Boom", "Boom!", nil)
+	}
+	if n.ShouldReport("Boom") {
+		t.Error("expected no report over the quota")
+	}
+	if stats := n.Stats(); stats.DryRun != 2 || stats.OverQuota != 0 {
+		t.Errorf("expected ShouldReport not to count, got: %+v", stats)
+	}
+	clock.now = clock.now.Add(time.Hour)
+	if !n.ShouldReport("Boom") {
+		t.Error("expected a report in the next hour")
+	}
+}
+
+func TestShouldReportBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	clock := &fakeClock{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	n := NewNotifier("key", WithEndpoint(server.URL), WithClock(clock), WithBreaker(1, time.Minute))
+	n.Notify(errors.New("Boom!"))
+	if n.ShouldReport("Boom") {
+		t.Error("expected no report while the breaker is open")
+	}
+
+	// Checking doesn't take the probe of the breaker.
+	clock.now = clock.now.Add(time.Minute)
+	if !n.ShouldReport("Boom") || !n.ShouldReport("Boom") {
+		t.Error("expected a report after the cooldown")
+	}
+	if !n.state.breaker.allow(1, time.Minute, clock.now) {
+		t.Error("expected the probe to be let through")
+	}
+}
@@ -0,0 +1,82 @@
+// Package prometheus exposes the metrics of an airbrake notifier in the
+// Prometheus text format, without depending on the Prometheus client.
+//
+// Example:
+//
+//	http.Handle("/metrics/airbrake", prometheus.Handler(nil))
+package prometheus
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/tobi/airbrake-go"
+)
+
+// Handler serves the metrics of the notifier, or of the package-level
+// functions if it is nil.
+func Handler(n *airbrake.Notifier) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stats, latency := airbrake.CurrentStats(), airbrake.CurrentLatency()
+		if n != nil {
+			stats, latency = n.Stats(), n.Latency()
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		Write(w, stats, latency)
+	})
+}
+
+// Write renders the metrics:
+//
+//	airbrake_notices_sent_total        counter
+//	airbrake_failures_total            counter
+//	airbrake_notices_dropped_total     counter, by reason
+//	airbrake_queue_depth               gauge
+//	airbrake_delivery_latency_seconds  histogram
+func Write(w io.Writer, stats airbrake.Stats, latency airbrake.Histogram) error {
+	p := &printer{w: w}
+	p.header("airbrake_notices_sent_total", "counter", "Notices delivered to the endpoint.")
+	p.printf("airbrake_notices_sent_total %d\n", stats.Sent)
+
+	p.header("airbrake_failures_total", "counter", "Notices that could not be delivered.")
+	p.printf("airbrake_failures_total %d\n", stats.Failed)
+
+	p.header("airbrake_notices_dropped_total", "counter", "Notices dropped before delivery.")
+	p.printf("airbrake_notices_dropped_total{reason=\"circuit_open\"} %d\n", stats.ShortCircuited)
+	p.printf("airbrake_notices_dropped_total{reason=\"queue_full\"} %d\n", stats.Dropped)
+	p.printf("airbrake_notices_dropped_total{reason=\"client_disconnect\"} %d\n", stats.Ignored)
+	p.printf("airbrake_notices_dropped_total{reason=\"duplicate\"} %d\n", stats.Suppressed)
+	p.printf("airbrake_notices_dropped_total{reason=\"sampled\"} %d\n", stats.Sampled)
+	p.printf("airbrake_notices_dropped_total{reason=\"quota\"} %d\n", stats.OverQuota)
+
+	p.header("airbrake_queue_depth", "gauge", "Notices waiting in the queue.")
+	p.printf("airbrake_queue_depth %d\n", stats.QueueDepth)
+
+	p.header("airbrake_delivery_latency_seconds", "histogram", "Time taken to post the notices.")
+	for i, bucket := range latency.Buckets {
+		le := strconv.FormatFloat(bucket.Seconds(), 'g', -1, 64)
+		p.printf("airbrake_delivery_latency_seconds_bucket{le=\"%s\"} %d\n", le, latency.Counts[i])
+	}
+	p.printf("airbrake_delivery_latency_seconds_bucket{le=\"+Inf\"} %d\n", latency.Count)
+	p.printf("airbrake_delivery_latency_seconds_sum %g\n", latency.Sum.Seconds())
+	p.printf("airbrake_delivery_latency_seconds_count %d\n", latency.Count)
+	return p.err
+}
+
+// printer keeps the first write error.
+type printer struct {
+	w   io.Writer
+	err error
+}
+
+func (p *printer) header(name, kind, help string) {
+	p.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (p *printer) printf(format string, args ...interface{}) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, args...)
+	}
+}
@@ -0,0 +1,35 @@
+package prometheus
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tobi/airbrake-go"
+)
+
+func TestHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	n := airbrake.NewNotifier("key", airbrake.WithEndpoint(server.URL))
+	n.Notify(errors.New("Boom!"))
+
+	w := httptest.NewRecorder()
+	Handler(n).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	for _, expected := range []string{
+		"# TYPE airbrake_notices_sent_total counter\nairbrake_notices_sent_total 1\n",
+		"airbrake_failures_total 0\n",
+		"airbrake_queue_depth 0\n",
+		"airbrake_delivery_latency_seconds_bucket{le=\"10\"} 1\n",
+		"airbrake_delivery_latency_seconds_bucket{le=\"+Inf\"} 1\n",
+		"airbrake_delivery_latency_seconds_count 1\n",
+	} {
+		if !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("%q not found in:\n%s", expected, w.Body)
+		}
+	}
+}
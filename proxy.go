@@ -0,0 +1,69 @@
+package airbrake
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// remoteIP returns the address of the peer that sent the request,
+// which may be a proxy.
+func remoteIP(request *http.Request) string {
+	ip, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		ip = request.RemoteAddr
+	}
+	return ip
+}
+
+// trusted checks whether the proxy at the address is trusted, i.e. whether its
+// X-Forwarded-* headers are.
+func (n *Notifier) trusted(addr string) bool {
+	if n.trustedProxies == nil {
+		return internal(addr)
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range n.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// externalURL reconstructs the URL requested by the client from the path,
+// with the scheme and host of the X-Forwarded-Proto and X-Forwarded-Host
+// headers set by trusted proxies, or else of the request itself.
+func externalURL(request *http.Request, path string, trusted func(string) bool) string {
+	if strings.Contains(path, "://") {
+		return path
+	}
+	scheme, host := "http", request.Host
+	if request.TLS != nil {
+		scheme = "https"
+	}
+	if trusted(remoteIP(request)) {
+		// The first proxy, the one the client connected to, comes first.
+		if proto := forwarded(request.Header.Get("X-Forwarded-Proto")); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if forwardedHost := forwarded(request.Header.Get("X-Forwarded-Host")); forwardedHost != "" {
+			host = forwardedHost
+		}
+	}
+	if host == "" {
+		return path
+	}
+	return scheme + "://" + host + path
+}
+
+// forwarded returns the first value of a comma-separated header.
+func forwarded(header string) string {
+	if i := strings.IndexByte(header, ','); i >= 0 {
+		header = header[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(header))
+}
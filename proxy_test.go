@@ -0,0 +1,104 @@
+package airbrake
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExternalURL(t *testing.T) {
+	for _, sample := range []struct {
+		remote, proto, host, out string
+		tls                      bool
+	}{
+		{"203.0.113.9:1234", "", "", "http://shop.internal:8080/cart?id=1", false},
+		{"203.0.113.9:1234", "", "", "https://shop.internal:8080/cart?id=1", true},
+		{"203.0.113.9:1234", "https", "shop.example.com", "http://shop.internal:8080/cart?id=1", false},
+		{"10.0.0.2:1234", "https", "shop.example.com", "https://shop.example.com/cart?id=1", false},
+		{"10.0.0.2:1234", "https, http", "shop.example.com, lb.internal", "https://shop.example.com/cart?id=1", false},
+		{"10.0.0.2:1234", "javascript", "", "http://shop.internal:8080/cart?id=1", false},
+	} {
+		request := httptest.NewRequest("GET", "http://shop.internal:8080/cart?id=1", nil)
+		request.RemoteAddr = sample.remote
+		if sample.tls {
+			request.TLS = &tls.ConnectionState{}
+		}
+		if sample.proto != "" {
+			request.Header.Set("X-Forwarded-Proto", sample.proto)
+		}
+		if sample.host != "" {
+			request.Header.Set("X-Forwarded-Host", sample.host)
+		}
+		if result := externalURL(request, "/cart?id=1", internal); result != sample.out {
+			t.Errorf("expected: %s got: %s", sample.out, result)
+		}
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	_, network, _ := net.ParseCIDR("198.51.100.0/24")
+	n := NewNotifier("key", WithTrustedProxies(network), WithAbsoluteURL(true))
+
+	request := httptest.NewRequest("GET", "/cart", nil)
+	request.RemoteAddr = "198.51.100.7:1234"
+	request.Header.Set("X-Forwarded-For", "203.0.113.9")
+	request.Header.Set("X-Forwarded-Host", "shop.example.com")
+	request.Header.Set("X-Forwarded-Proto", "https")
+	notice := n.newNotice(errors.New("Boom!"), request, 0)
+	if notice.Request.URL != "https://shop.example.com/cart" || notice.Client.IP != "203.0.113.9" {
+		t.Errorf("unexpected request: %s %+v", notice.Request.URL, notice.Client)
+	}
+
+	request.RemoteAddr = "10.0.0.2:1234"
+	notice = n.newNotice(errors.New("Boom!"), request, 0)
+	if notice.Request.URL != "http://example.com/cart" || notice.Client.IP != "10.0.0.2" {
+		t.Errorf("unexpected request: %s %+v", notice.Request.URL, notice.Client)
+	}
+}
+
+func TestClient(t *testing.T) {
+	request := httptest.NewRequest("GET", "/cart", nil)
+	request.RemoteAddr = "203.0.113.9:1234"
+	request.Header.Set("User-Agent", "curl/7.64.1")
+	request.Header.Set("Referer", "https://example.com/")
+	notice := NewNotifier("key").newNotice(errors.New("Boom!"), request, 0)
+
+	if c := notice.Client; c == nil || *c != (Client{"203.0.113.9", "curl/7.64.1", "https://example.com/"}) {
+		t.Fatalf("unexpected client: %+v", c)
+	}
+	if len(notice.Request.Params) != 0 || len(notice.Request.CGIData) != 2 {
+		t.Errorf("unexpected request: %+v", notice.Request)
+	}
+	if b, _ := XML.Serialize(notice); !bytes.Contains(b, []byte(`<var key="HTTP_REFERER">https://example.com/</var>`)) {
+		t.Errorf("unexpected payload: %s", b)
+	}
+
+	b, _ := JSON.Serialize(notice)
+	var payload struct{ Context map[string]interface{} }
+	json.Unmarshal(b, &payload)
+	if payload.Context["userAddr"] != "203.0.113.9" || payload.Context["userAgent"] != "curl/7.64.1" || payload.Context["referer"] != "https://example.com/" {
+		t.Errorf("unexpected context: %v", payload.Context)
+	}
+}
+
+func TestClientHeaderAllowlist(t *testing.T) {
+	request := httptest.NewRequest("GET", "/cart", nil)
+	request.RemoteAddr = "203.0.113.9:1234"
+	request.Header.Set("User-Agent", "curl/7.64.1")
+	request.Header.Set("Referer", "https://example.com/reset?token=secret")
+	notice := NewNotifier("key", WithHeaderAllowlist("User-Agent")).newNotice(errors.New("Boom!"), request, 0)
+
+	if c := notice.Client; c == nil || *c != (Client{IP: "203.0.113.9", UserAgent: "curl/7.64.1"}) {
+		t.Fatalf("unexpected client: %+v", c)
+	}
+	if b, _ := JSON.Serialize(notice); bytes.Contains(b, []byte("token")) {
+		t.Errorf("unexpected payload: %s", b)
+	}
+	if b, _ := XML.Serialize(notice); bytes.Contains(b, []byte("token")) {
+		t.Errorf("unexpected payload: %s", b)
+	}
+}
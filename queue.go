@@ -0,0 +1,140 @@
+package airbrake
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// queue delivers notices asynchronously, in batches.
+type queue struct {
+	pending int64 // first, for 64-bit alignment of atomic operations
+	once    sync.Once
+	notices chan queued
+}
+
+// QueuePolicy decides what happens to a notice when the queue is full.
+type QueuePolicy int
+
+const (
+	// DropNewest drops the notice being queued.
+	DropNewest QueuePolicy = iota
+	// DropOldest drops the notice that has been waiting the longest,
+	// to make room for the new one.
+	DropOldest
+	// Block makes the caller wait until there is room in the queue.
+	Block
+)
+
+// queued is a notice waiting for delivery by the notifier that built it.
+type queued struct {
+	notifier *Notifier
+	notice   *Notice
+}
+
+// enqueue hands the notice to the background worker, starting it if needed.
+// If the queue is full, the queue policy decides which notice is dropped,
+// if any.
+func (n *Notifier) enqueue(notice *Notice) {
+	q := &n.state.queue
+	q.once.Do(func() {
+		q.notices = make(chan queued, n.queueSize)
+		go q.run()
+	})
+
+	atomic.AddInt64(&q.pending, 1)
+	item := queued{n, notice}
+	if n.queuePolicy == Block {
+		q.notices <- item
+		return
+	}
+	for {
+		select {
+		case q.notices <- item:
+			return
+		default:
+		}
+		if n.queuePolicy == DropNewest {
+			q.drop(item)
+			return
+		}
+		select {
+		case oldest := <-q.notices:
+			q.drop(oldest)
+		default:
+		}
+	}
+}
+
+// deadlineQueueSize is the size of the queue started for the notices
+// diverted by the DeadlineMargin setting, without QueueSize.
+const deadlineQueueSize = 100
+
+// doomed reports whether the deadline of the notice is too close for it to
+// be delivered synchronously.
+func (n *Notifier) doomed(notice *Notice) bool {
+	if n.deadlineMargin <= 0 || notice.deadline.IsZero() || (n.queueSize > 0 && n.syncTimeout <= 0) {
+		return false
+	}
+	return notice.deadline.Sub(n.clock.Now()) < n.deadlineMargin
+}
+
+// divert queues a doomed notice, starting a queue if needed.
+func (n *Notifier) divert(notice *Notice) {
+	if n.queueSize <= 0 {
+		queued := *n
+		queued.queueSize = deadlineQueueSize
+		n = &queued
+	}
+	n.enqueue(notice)
+}
+
+// drop accounts for a notice dropped from the queue.
+func (q *queue) drop(item queued) {
+	atomic.AddInt64(&q.pending, -1)
+	atomic.AddUint64(&item.notifier.state.stats.dropped, 1)
+	finish(item.notice.done, NoticeResult{}, ErrDropped)
+}
+
+// run delivers the queued notices. Up to batchSize notices, collected for at
+// most batchDelay, are sent back to back over the same connection, since the
+// notifier API accepts a single notice per request.
+func (q *queue) run() {
+	for first := range q.notices {
+		batch := []queued{first}
+		timeout := time.After(first.notifier.batchDelay)
+	collect:
+		for len(batch) < first.notifier.batchSize {
+			select {
+			case next := <-q.notices:
+				batch = append(batch, next)
+			case <-timeout:
+				break collect
+			}
+		}
+
+		for _, item := range batch {
+			item.notifier.deliver(item.notice)
+			atomic.AddInt64(&q.pending, -1)
+		}
+	}
+}
+
+// Flush waits until the queued notices have been delivered, or the timeout
+// expires. It reports whether the queue was drained.
+func (n *Notifier) Flush(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for atomic.LoadInt64(&n.state.queue.pending) > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
+
+// Flush waits for the notices queued by the package-level functions.
+// See Notifier.Flush.
+func Flush(timeout time.Duration) bool {
+	return defaultNotifier().Flush(timeout)
+}
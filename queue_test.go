@@ -0,0 +1,148 @@
+package airbrake
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		atomic.AddInt32(&requests, 1)
+	}))
+	defer server.Close()
+
+	n := NewNotifier("key", WithEndpoint(server.URL), WithQueueSize(2), WithBatch(2, 10*time.Millisecond))
+	for i := 0; i < 5; i++ {
+		if err := n.Notify(errors.New("Boom!")); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if n.Flush(10 * time.Millisecond) {
+		t.Error("expected flush to time out while the endpoint is blocked")
+	}
+	close(release)
+	if !n.Flush(time.Second) {
+		t.Fatal("expected flush to drain the queue")
+	}
+
+	stats := n.Stats()
+	if stats.Sent != uint64(atomic.LoadInt32(&requests)) || stats.Sent+stats.Dropped != 5 || stats.Dropped == 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestQueuePolicy(t *testing.T) {
+	for policy, expected := range map[QueuePolicy][]string{
+		DropNewest: {"0", "1", "2"},
+		DropOldest: {"0", "3", "4"},
+		Block:      {"0", "1", "2", "3", "4"},
+	} {
+		var mu sync.Mutex
+		var received []string
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+			var notice Notice
+			body, _ := ioutil.ReadAll(r.Body)
+			xml.Unmarshal(body, &notice)
+			mu.Lock()
+			received = append(received, notice.Error.Message)
+			mu.Unlock()
+		}))
+
+		n := NewNotifier("key", WithEndpoint(server.URL), WithQueueSize(2), WithBatch(1, 0), WithQueuePolicy(policy))
+		n.Notify(errors.New("0"))
+		// Wait for the worker to pick up the first notice, so the queue
+		// then holds two notices.
+		for len(n.state.queue.notices) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+		done := make(chan struct{})
+		go func() {
+			for i := 1; i < 5; i++ {
+				n.Notify(errors.New(strconv.Itoa(i)))
+			}
+			close(done)
+		}()
+		if policy != Block {
+			<-done
+		}
+		close(release)
+		<-done
+		n.Flush(time.Second)
+		server.Close()
+
+		if !reflect.DeepEqual(received, expected) {
+			t.Errorf("%d expected: %v got: %v", policy, expected, received)
+		}
+		if dropped := n.Stats().Dropped; dropped != uint64(5-len(expected)) {
+			t.Errorf("%d unexpected drops: %d", policy, dropped)
+		}
+	}
+}
+
+func TestSyncTimeout(t *testing.T) {
+	var requests int32
+	status := int32(http.StatusOK)
+	slow := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 2 {
+			<-slow
+		}
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer server.Close()
+	defer close(slow)
+
+	var results []error
+	n := NewNotifier("key", WithEndpoint(server.URL), WithQueueSize(10), WithSyncTimeout(50*time.Millisecond))
+	n.OnDeliveryFailure(func(notice Notice, err error) { results = append(results, err) })
+
+	if err := n.Notify(errors.New("Boom!")); err != nil || n.Stats().Sent != 1 {
+		t.Errorf("expected a synchronous delivery, got: %v %+v", err, n.Stats())
+	}
+	if err := n.Notify(errors.New("Slow")); err != nil {
+		t.Errorf("expected the notice to be queued, got: %v", err)
+	}
+	if !n.Flush(time.Second) || n.Stats().Sent != 2 {
+		t.Errorf("expected the queued notice to be delivered, got: %+v", n.Stats())
+	}
+
+	atomic.StoreInt32(&status, http.StatusUnprocessableEntity)
+	if err := n.Notify(errors.New("Rejected")); err != badResponse || len(results) != 1 {
+		t.Errorf("expected a synchronous rejection, got: %v %v", err, results)
+	}
+}
+
+func TestDeadlineMargin(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	n := NewNotifier("key", WithEndpoint(server.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := n.NotifyContext(ctx, errors.New("Shutting down")); err != nil {
+		t.Errorf("expected the notice to be queued, got: %v", err)
+	}
+	close(release)
+	if !n.Flush(time.Second) || n.Stats().Sent != 1 {
+		t.Errorf("expected the queued notice to be delivered, got: %+v", n.Stats())
+	}
+}
@@ -0,0 +1,65 @@
+package airbrake
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// quota counts the notices sent in the current hour.
+type quota struct {
+	mu    sync.Mutex
+	start time.Time
+	sent  int
+}
+
+// take reports whether a notice exceeds the quota. For the first one to do
+// so in the current hour, it also returns when the hour is over.
+func (q *quota) take(limit int, now time.Time) (over bool, until time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if now.Sub(q.start) >= time.Hour {
+		q.start, q.sent = now, 0
+	}
+	q.sent++
+	if q.sent == limit+1 {
+		until = q.start.Add(time.Hour)
+	}
+	return q.sent > limit, until
+}
+
+// exhausted reports whether the next notice would exceed the quota,
+// without counting it.
+func (q *quota) exhausted(limit int, now time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return now.Sub(q.start) < time.Hour && q.sent >= limit
+}
+
+// overQuota reports whether the notice exceeds the hourly quota, in which
+// case it is dropped. The first time in the hour, a warning is sent instead.
+func (n *Notifier) overQuota() bool {
+	if n.hourlyQuota <= 0 {
+		return false
+	}
+	over, until := n.state.quota.take(n.hourlyQuota, n.clock.Now())
+	if !over {
+		return false
+	}
+	atomic.AddUint64(&n.state.stats.overQuota, 1)
+	if !until.IsZero() {
+		message := fmt.Sprintf("More than %d notices in an hour, the others are dropped until %s",
+			n.hourlyQuota, until.Format(time.RFC3339))
+		notice := n.newNotice(errors.New(message), nil, 0)
+		notice.Error.Class = "AirbrakeQuotaExceeded"
+		notice.Severity = SeverityWarning
+		if notice = n.filter(notice); notice != nil {
+			n.dispatch(notice)
+		}
+	}
+	return true
+}
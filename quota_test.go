@@ -0,0 +1,28 @@
+package airbrake
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHourlyQuota(t *testing.T) {
+	var classes []string
+	clock := &fakeClock{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	n := NewNotifier("key", WithDryRun(true), WithClock(clock), WithHourlyQuota(3), WithFilter(func(notice *Notice) *Notice {
+		classes = append(classes, notice.Error.Class)
+		return notice
+	}))
+
+	for i := 0; i < 5; i++ {
+		n.NotifyMessage("Boom", "Boom!", nil)
+	}
+	clock.now = clock.now.Add(time.Hour)
+	n.NotifyMessage("Boom", "Boom!", nil)
+
+	if stats := n.Stats(); stats.DryRun != 5 || stats.OverQuota != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if len(classes) != 7 || classes[4] != "AirbrakeQuotaExceeded" || classes[6] != "Boom" {
+		t.Errorf("unexpected notices: %v", classes)
+	}
+}
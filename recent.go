@@ -0,0 +1,80 @@
+package airbrake
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxRecentErrors bounds how many errors RecentErrors remembers.
+// The least recently seen are forgotten first.
+const maxRecentErrors = 100
+
+// RecentError summarizes the notices reported with the same Key.
+type RecentError struct {
+	Key      string
+	Class    string
+	Message  string
+	Count    uint64
+	LastSeen time.Time
+}
+
+// recent indexes the errors reported by their key.
+type recent struct {
+	mu     sync.Mutex
+	errors map[string]*RecentError
+}
+
+func (r *recent) record(notice *Notice, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := notice.Key()
+	e := r.errors[key]
+	if e == nil {
+		if r.errors == nil {
+			r.errors = make(map[string]*RecentError)
+		}
+		if len(r.errors) >= maxRecentErrors {
+			r.evict()
+		}
+		e = &RecentError{Key: key, Class: notice.Error.Class, Message: notice.Error.Message}
+		r.errors[key] = e
+	}
+	e.Count++
+	e.LastSeen = now
+}
+
+// evict forgets the least recently seen error.
+func (r *recent) evict() {
+	var oldest *RecentError
+	for _, e := range r.errors {
+		if oldest == nil || e.LastSeen.Before(oldest.LastSeen) {
+			oldest = e
+		}
+	}
+	delete(r.errors, oldest.Key)
+}
+
+// RecentErrors returns the errors recently reported by the package-level
+// functions. See Notifier.RecentErrors.
+func RecentErrors() []RecentError {
+	return defaultNotifier().RecentErrors()
+}
+
+// RecentErrors returns the errors recently reported, most recently seen first,
+// with how many times they were, so that operators can inspect them without
+// going to the dashboard. Notices dropped by the filters are not counted,
+// but duplicates suppressed by the DedupWindow are.
+func (n *Notifier) RecentErrors() []RecentError {
+	r := &n.state.recent
+	r.mu.Lock()
+	errors := make([]RecentError, 0, len(r.errors))
+	for _, e := range r.errors {
+		errors = append(errors, *e)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(errors, func(i, j int) bool { return errors[i].LastSeen.After(errors[j].LastSeen) })
+	return errors
+}
@@ -0,0 +1,55 @@
+package airbrake
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNoticeKey(t *testing.T) {
+	newNotice := func(message string, line int) *Notice {
+		notice := &Notice{}
+		notice.Error.Class = "*errors.errorString"
+		notice.Error.Message = message
+		notice.Error.Backtrace = []Line{{File: "[PROJECT_ROOT]/billing/invoice.go", Line: line}, {File: "[PROJECT_ROOT]/main.go", Line: 12}}
+		return notice
+	}
+
+	key := newNotice("Boom!", 42).Key()
+	if len(key) != 16 || newNotice("Boom!", 42).Key() != key {
+		t.Errorf("expected the same key, got: %s %s", key, newNotice("Boom!", 42).Key())
+	}
+	if newNotice("Boom!", 43).Key() == key || newNotice("Other", 42).Key() == key {
+		t.Error("expected different keys")
+	}
+}
+
+func TestRecentErrors(t *testing.T) {
+	clock := &fakeClock{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	n := NewNotifier("key", WithDryRun(true), WithClock(clock))
+	notify := func(message string) { n.Notify(errors.New(message)) }
+
+	for _, message := range []string{"Boom!", "Other", "Boom!"} {
+		notify(message)
+		clock.now = clock.now.Add(time.Second)
+	}
+
+	recent := n.RecentErrors()
+	if len(recent) != 2 {
+		t.Fatalf("expected 2 errors, got: %+v", recent)
+	}
+	if recent[0].Message != "Boom!" || recent[0].Count != 2 || !recent[0].LastSeen.Equal(clock.now.Add(-time.Second)) {
+		t.Errorf("unexpected error: %+v", recent[0])
+	}
+	if recent[1].Message != "Other" || recent[1].Count != 1 {
+		t.Errorf("unexpected error: %+v", recent[1])
+	}
+
+	for i := 0; i < maxRecentErrors; i++ {
+		clock.now = clock.now.Add(time.Second)
+		n.NotifyMessage("Filler", string(rune('a'+i%26))+string(rune('a'+i/26)), nil)
+	}
+	if recent := n.RecentErrors(); len(recent) != maxRecentErrors || recent[len(recent)-1].Message == "Other" {
+		t.Errorf("expected the oldest errors to be forgotten, got: %d", len(recent))
+	}
+}
@@ -0,0 +1,164 @@
+package airbrake
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"text/template"
+)
+
+// Template is implemented by the templates of text/template and html/template.
+type Template interface {
+	Name() string
+	Execute(w io.Writer, data interface{}) error
+}
+
+// ResponseError is reported for the failures to render a response, which
+// happen after the handler has otherwise succeeded, and are usually lost.
+type ResponseError struct {
+	Op       string // "write", "encode" or "execute"
+	Template string // the name of the template, for "execute"
+	Err      error
+}
+
+func (e *ResponseError) Error() string {
+	switch e.Op {
+	case "execute":
+		return fmt.Sprintf("executing template %s: %v", e.Template, e.Err)
+	case "encode":
+		return fmt.Sprintf("encoding the response: %v", e.Err)
+	}
+	return fmt.Sprintf("writing the response: %v", e.Err)
+}
+
+func (e *ResponseError) Unwrap() error { return e.Err }
+
+// Execute executes the template with the data, and reports its error, or
+// panic, with the request. A panic is returned as an error.
+//
+// Example:
+//
+//	airbrake.Execute(page, w, data, r)
+func Execute(t Template, w io.Writer, data interface{}, r *http.Request) error {
+	return execute(defaultNotifier(), t, w, data, r)
+}
+
+// Execute works like the package-level Execute.
+func (n *Notifier) Execute(t Template, w io.Writer, data interface{}, r *http.Request) error {
+	return execute(n, t, w, data, r)
+}
+
+func execute(n *Notifier, t Template, w io.Writer, data interface{}, r *http.Request) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			n.capture(rec, r)
+			err = fmt.Errorf("airbrake: recovered from panic: %v", rec)
+		}
+	}()
+	if err := t.Execute(w, data); err != nil {
+		var execError template.ExecError
+		if rw, ok := w.(*ResponseWriter); ok && rw.err != nil && !errors.As(err, &execError) {
+			// An error of writing the response, already reported by Write.
+			return err
+		}
+		n.send(n.newNotice(&ResponseError{Op: "execute", Template: t.Name(), Err: err}, r, 1))
+		return err
+	}
+	return nil
+}
+
+// ResponseErrorHandler wraps the handler so that the errors of writing its
+// responses are reported, e.g. a body cut short, with NewResponseWriter.
+func ResponseErrorHandler(app http.Handler) http.Handler {
+	return responseErrorHandler(defaultNotifier, app)
+}
+
+// ResponseErrorHandler works like the package-level ResponseErrorHandler.
+func (n *Notifier) ResponseErrorHandler(app http.Handler) http.Handler {
+	return responseErrorHandler(func() *Notifier { return n }, app)
+}
+
+func responseErrorHandler(notifier func() *Notifier, app http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app.ServeHTTP(&ResponseWriter{ResponseWriter: w, notifier: notifier, request: r}, r)
+	})
+}
+
+// ResponseWriter reports the first error of writing the response, and the
+// errors of encoding or executing it with EncodeJSON and Execute, with the
+// request. Handlers wrapped by ResponseErrorHandler can type-assert their
+// http.ResponseWriter to it.
+type ResponseWriter struct {
+	http.ResponseWriter
+	notifier func() *Notifier
+	request  *http.Request
+	err      error
+}
+
+// NewResponseWriter wraps the response writer of the request, reporting
+// with the package-level functions.
+func NewResponseWriter(w http.ResponseWriter, r *http.Request) *ResponseWriter {
+	return &ResponseWriter{ResponseWriter: w, notifier: defaultNotifier, request: r}
+}
+
+// NewResponseWriter works like the package-level NewResponseWriter.
+func (n *Notifier) NewResponseWriter(w http.ResponseWriter, r *http.Request) *ResponseWriter {
+	return &ResponseWriter{ResponseWriter: w, notifier: func() *Notifier { return n }, request: r}
+}
+
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	written, err := w.ResponseWriter.Write(b)
+	if err != nil && w.err == nil {
+		w.err = err
+		w.report(&ResponseError{Op: "write", Err: err})
+	}
+	return written, err
+}
+
+// Flush flushes the underlying response writer, if it can.
+func (w *ResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack hijacks the underlying connection, if the response writer can.
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Unwrap returns the underlying response writer, for http.ResponseController.
+func (w *ResponseWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// EncodeJSON writes the value as JSON, with the application/json content
+// type unless one is set, and reports the error if it cannot be encoded.
+func (w *ResponseWriter) EncodeJSON(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		w.report(&ResponseError{Op: "encode", Err: err})
+		return err
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// Execute executes the template into the response, like the package-level
+// Execute. The errors of writing the response are only reported once.
+func (w *ResponseWriter) Execute(t Template, data interface{}) error {
+	return execute(w.notifier(), t, w, data, w.request)
+}
+
+func (w *ResponseWriter) report(e error) {
+	n := w.notifier()
+	n.send(n.newNotice(e, w.request, 1))
+}
@@ -0,0 +1,102 @@
+package airbrake
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// failingWriter fails to write the response.
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("short write") }
+
+func TestExecute(t *testing.T) {
+	var reported []*Notice
+	n := NewNotifier("key", capturing(&reported))
+	r := httptest.NewRequest("GET", "/invoices", nil)
+	page := template.Must(template.New("page").Parse(`{{ .Missing.Field }}`))
+
+	if err := n.Execute(page, httptest.NewRecorder(), struct{}{}, r); err == nil {
+		t.Error("expected an error")
+	}
+	panics := template.Must(template.New("panics").Funcs(template.FuncMap{
+		"boom": func() string { panic("Boom!") },
+	}).Parse(`{{ boom }}`))
+	if err := n.Execute(panics, httptest.NewRecorder(), nil, r); err == nil {
+		t.Error("expected an error")
+	}
+
+	if len(reported) != 2 {
+		t.Fatalf("expected 2 notices, got: %d", len(reported))
+	}
+	if reported[0].Error.Class != "*airbrake.ResponseError" || !strings.HasPrefix(reported[0].Error.Message, "executing template page: ") {
+		t.Errorf("unexpected error: %+v", reported[0].Error)
+	}
+	if reported[0].Request.URL != "/invoices" {
+		t.Errorf("unexpected URL: %s", reported[0].Request.URL)
+	}
+}
+
+func TestResponseWriter(t *testing.T) {
+	var reported []*Notice
+	n := NewNotifier("key", capturing(&reported))
+	page := template.Must(template.New("page").Parse(`{{ . }}`))
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := w.(*ResponseWriter)
+		if err := rw.EncodeJSON(func() {}); err == nil {
+			t.Error("expected an encoding error")
+		}
+		rw.Write([]byte("a"))
+		rw.Write([]byte("b"))
+		rw.Execute(page, "c")
+	})
+
+	n.ResponseErrorHandler(app).ServeHTTP(failingWriter{httptest.NewRecorder()}, httptest.NewRequest("GET", "/invoices", nil))
+
+	var messages []string
+	for _, notice := range reported {
+		messages = append(messages, notice.Error.Message)
+	}
+	if len(messages) != 2 || !strings.HasPrefix(messages[0], "encoding the response: json: unsupported type") ||
+		messages[1] != "writing the response: short write" {
+		t.Errorf("unexpected notices: %q", messages)
+	}
+
+	recorder := httptest.NewRecorder()
+	if err := NewResponseWriter(recorder, nil).EncodeJSON(map[string]int{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Body.String() != "{\"a\":1}\n" || recorder.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected response: %q %v", recorder.Body, recorder.Header())
+	}
+}
+
+func TestResponseWriterHijack(t *testing.T) {
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+		buf.Flush()
+	})
+	server := httptest.NewServer(NewNotifier("key").ResponseErrorHandler(app))
+	defer server.Close()
+
+	response, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("unexpected status: %d", response.StatusCode)
+	}
+}
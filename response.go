@@ -0,0 +1,94 @@
+package airbrake
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+const (
+	// maxResponseSize bounds how much of a response body is kept.
+	maxResponseSize = 64 << 10
+
+	// maxDrainSize bounds how much more of a response body is read and
+	// discarded to reuse the connection. Beyond that, it is cheaper to
+	// close it.
+	maxDrainSize = 256 << 10
+)
+
+// Response describes how the endpoint answered a delivered notice.
+type Response struct {
+	StatusCode int
+	Body       []byte
+
+	// ID and URL identify the notice on the endpoint, if it reported them.
+	ID  string
+	URL string
+
+	// Errors are the reasons the endpoint gave for rejecting the notice,
+	// e.g. the validation errors of a 422.
+	Errors []string
+}
+
+// ResponseDecoder interprets the answer of the endpoint, of which body is
+// the beginning. It returns an error if the notice was rejected, which is
+// passed to the delivery failure callbacks. Otherwise, the response is passed
+// to the success callbacks.
+//
+// Custom decoders handle the quirks of specific servers, e.g. Errbit
+// versions answering with other bodies or status codes. They usually
+// wrap DecodeResponse.
+type ResponseDecoder func(response *http.Response, body []byte) (Response, error)
+
+// DecodeResponse is the default ResponseDecoder. It rejects the notice if
+// the status is not 2xx, and reads the notice id and url out of the body, e.g.
+//
+//	<notice><id>1234</id><url>http://errbit.example.com/locate/1234</url></notice>
+//
+// or the errors of a rejected notice, e.g.
+//
+//	<errors><error>Api key is invalid</error></errors>
+func DecodeResponse(response *http.Response, body []byte) (Response, error) {
+	r := Response{StatusCode: response.StatusCode, Body: body}
+	var decoded struct {
+		XMLName xml.Name
+		ID      string   `xml:"id"`
+		URL     string   `xml:"url"`
+		Errors  []string `xml:"error"`
+	}
+	if xml.Unmarshal(body, &decoded) == nil {
+		switch decoded.XMLName.Local {
+		case "errors":
+			r.Errors = decoded.Errors
+		default:
+			r.ID, r.URL = decoded.ID, decoded.URL
+		}
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return r, badResponse
+	}
+	return r, nil
+}
+
+// OnDeliverySuccess registers a callback for every notice delivered by the
+// package-level functions. See Notifier.OnDeliverySuccess.
+func OnDeliverySuccess(callback func(Notice, Response)) {
+	onSuccess = append(onSuccess, callback)
+}
+
+// OnDeliveryFailure registers a callback for every notice the package-level
+// functions failed to deliver. See Notifier.OnDeliveryFailure.
+func OnDeliveryFailure(callback func(Notice, error)) {
+	onFailure = append(onFailure, callback)
+}
+
+// OnDeliverySuccess registers a callback that is run after a notice has been
+// accepted by the endpoint, e.g. to log its URL.
+func (n *Notifier) OnDeliverySuccess(callback func(Notice, Response)) {
+	n.onSuccess = append(n.onSuccess, callback)
+}
+
+// OnDeliveryFailure registers a callback that is run when a notice could not
+// be delivered, including when it was dropped by the circuit breaker.
+func (n *Notifier) OnDeliveryFailure(callback func(Notice, error)) {
+	n.onFailure = append(n.onFailure, callback)
+}
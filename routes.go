@@ -0,0 +1,177 @@
+package airbrake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultRoutesInterval is how often the route stats are posted, unless
+// set otherwise.
+const defaultRoutesInterval = 15 * time.Second
+
+// routeKey identifies the requests aggregated together: those of a route,
+// answered with the same status, within the same minute.
+type routeKey struct {
+	Method     string    `json:"method"`
+	Route      string    `json:"route"`
+	StatusCode int       `json:"statusCode"`
+	Time       time.Time `json:"time"`
+}
+
+// routeStat is the latency distribution of the requests of a routeKey,
+// in milliseconds.
+type routeStat struct {
+	routeKey
+	Count int     `json:"count"`
+	Sum   float64 `json:"sum"`
+	Sumsq float64 `json:"sumsq"`
+}
+
+// routes aggregates the route stats until they are posted.
+type routes struct {
+	mu    sync.Mutex
+	once  sync.Once
+	stats map[routeKey]*routeStat
+}
+
+// RouteHandler "middleware".
+// Wraps the http handler so that the latency and status of its requests are
+// reported as the stats of the route, e.g. "/users/:id", when RoutesEndpoint
+// is set.
+//
+// Example:
+//
+//	http.Handle("/users/", airbrake.RouteHandler("/users/:id", users))
+func RouteHandler(route string, app http.Handler) http.Handler {
+	return routeHandler(defaultNotifier, route, app)
+}
+
+// RouteHandler works like the package-level RouteHandler.
+func (n *Notifier) RouteHandler(route string, app http.Handler) http.Handler {
+	return routeHandler(func() *Notifier { return n }, route, app)
+}
+
+func routeHandler(notifier func() *Notifier, route string, app http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := notifier()
+		start := n.clock.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		app.ServeHTTP(recorder, r)
+		n.RecordRoute(r.Method, route, recorder.status, start, n.clock.Now().Sub(start))
+	})
+}
+
+// RecordRoute records a request for the route stats of the package-level
+// functions. See Notifier.RecordRoute.
+func RecordRoute(method, route string, statusCode int, start time.Time, d time.Duration) {
+	defaultNotifier().RecordRoute(method, route, statusCode, start, d)
+}
+
+// RecordRoute records a request, started at start and served in d, in the
+// stats of the route, for routers that don't fit RouteHandler. The stats are
+// aggregated by minute and posted to the RoutesEndpoint in the background,
+// every RoutesInterval. It does nothing if the RoutesEndpoint is not set.
+func (n *Notifier) RecordRoute(method, route string, statusCode int, start time.Time, d time.Duration) {
+	if n.routesEndpoint == "" {
+		return
+	}
+	r := &n.state.routes
+	r.once.Do(func() { go n.reportRoutes() })
+
+	key := routeKey{method, route, statusCode, start.UTC().Truncate(time.Minute)}
+	ms := float64(d) / float64(time.Millisecond)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stats == nil {
+		r.stats = make(map[routeKey]*routeStat)
+	}
+	s := r.stats[key]
+	if s == nil {
+		s = &routeStat{routeKey: key}
+		r.stats[key] = s
+	}
+	s.Count++
+	s.Sum += ms
+	s.Sumsq += ms * ms
+}
+
+// reportRoutes posts the route stats every interval.
+func (n *Notifier) reportRoutes() {
+	interval := n.routesInterval
+	if interval <= 0 {
+		interval = defaultRoutesInterval
+	}
+	for range time.Tick(interval) {
+		if err := n.FlushRoutes(context.Background()); err != nil {
+			log.Printf("Airbrake error: %s", err)
+		}
+	}
+}
+
+// FlushRoutes posts the route stats recorded by the package-level functions.
+// See Notifier.FlushRoutes.
+func FlushRoutes(ctx context.Context) error {
+	return defaultNotifier().FlushRoutes(ctx)
+}
+
+// FlushRoutes posts the route stats recorded so far, without waiting for the
+// next interval, e.g. before exiting. The stats are dropped if the delivery
+// fails.
+func (n *Notifier) FlushRoutes(ctx context.Context) error {
+	r := &n.state.routes
+	r.mu.Lock()
+	stats := r.stats
+	r.stats = nil
+	r.mu.Unlock()
+	if len(stats) == 0 || n.routesEndpoint == "" {
+		return nil
+	}
+
+	payload := struct {
+		Environment string       `json:"environment"`
+		Routes      []*routeStat `json:"routes"`
+	}{Environment: n.environment}
+	for _, s := range stats {
+		payload.Routes = append(payload.Routes, s)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if n.verbose {
+		log.Printf("Airbrake route stats for endpoint %s: %s", n.routesEndpoint, body)
+	}
+
+	request, err := http.NewRequestWithContext(delivery(ctx), "POST", n.routesEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range n.deliveryHeaders {
+		request.Header[k] = v
+	}
+	if n.userAgent != "" {
+		request.Header.Set("User-Agent", n.userAgent)
+	}
+	request.Header.Set("Authorization", "Bearer "+n.apiKey)
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := n.httpClient().Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(response.Body, maxDrainSize))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return badResponse
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package airbrake
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRouteHandler(t *testing.T) {
+	var authorization string
+	var received struct {
+		Environment string      `json:"environment"`
+		Routes      []routeStat `json:"routes"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	clock := &fakeClock{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	n := NewNotifier("key", WithEnvironment("production"), WithClock(clock), WithRoutes(server.URL, time.Hour))
+	handler := n.RouteHandler("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clock.now = clock.now.Add(10 * time.Millisecond)
+		if r.URL.Path == "/users/0" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	for _, path := range []string{"/users/1", "/users/2", "/users/0"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	if err := n.FlushRoutes(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if authorization != "Bearer key" || received.Environment != "production" || len(received.Routes) != 2 {
+		t.Fatalf("unexpected stats: %s %+v", authorization, received)
+	}
+	for _, s := range received.Routes {
+		expected := routeStat{routeKey{"GET", "/users/:id", s.StatusCode, time.Date(2020, 1, 2, 3, 4, 0, 0, time.UTC)}, 2, 20, 200}
+		if s.StatusCode == http.StatusNotFound {
+			expected.Count, expected.Sum, expected.Sumsq = 1, 10, 100
+		}
+		if !s.Time.Equal(expected.Time) || s.Method != expected.Method || s.Route != expected.Route ||
+			s.Count != expected.Count || s.Sum != expected.Sum || s.Sumsq != expected.Sumsq {
+			t.Errorf("expected: %+v got: %+v", expected, s)
+		}
+	}
+
+	if err := n.FlushRoutes(context.Background()); err != nil || len(received.Routes) != 2 {
+		t.Errorf("expected nothing to flush, got: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package airbrake
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// id matches path segments that look like IDs: numbers, UUIDs and long
+// hexadecimal strings, like hashes or object IDs.
+var id = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{24,})$`)
+
+// ScrubIDs replaces the path segments of the URL that look like IDs with
+// :id, e.g. /users/18273/orders/991 becomes /users/:id/orders/:id.
+// The query string is left as is. It is meant for the ScrubURL setting.
+func ScrubIDs(url string) string {
+	path, query := url, ""
+	if i := strings.IndexByte(url, '?'); i >= 0 {
+		path, query = url[:i], url[i:]
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if id.MatchString(segment) {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/") + query
+}
+
+// ScrubPattern returns a ScrubURL function replacing the matches of the
+// pattern, e.g. ScrubPattern(`/tokens/[^/]+`, "/tokens/:token").
+// The replacement can refer to submatches like regexp.ReplaceAllString.
+func ScrubPattern(pattern, replacement string) func(url string) string {
+	re := regexp.MustCompile(pattern)
+	return func(url string) string {
+		return re.ReplaceAllString(url, replacement)
+	}
+}
+
+// Patterns of sensitive values, for the ScrubValues setting.
+var (
+	// PAN matches payment card numbers: 13 to 19 digits, possibly grouped
+	// with spaces or dashes. Only those passing the Luhn check are scrubbed.
+	PAN = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+	// JWT matches JSON web tokens.
+	JWT = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+`)
+
+	// AWSAccessKey matches AWS access key IDs.
+	AWSAccessKey = regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)
+
+	// BearerToken matches the credentials of bearer authorizations.
+	BearerToken = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]+=*`)
+
+	// SensitiveValues are all of the above.
+	SensitiveValues = []*regexp.Regexp{PAN, JWT, AWSAccessKey, BearerToken}
+)
+
+// filtered replaces the scrubbed values.
+const filtered = "[FILTERED]"
+
+// scrubValues replaces the matches of the patterns in the message, params and
+// CGI data of the notice.
+func (n *Notice) scrubValues(patterns []*regexp.Regexp) {
+	if len(patterns) == 0 {
+		return
+	}
+	n.Error.Message = scrubValue(n.Error.Message, patterns)
+	if r := n.Request; r != nil {
+		r.URL = scrubValue(r.URL, patterns)
+		for _, v := range []vars{r.Params, r.Session, r.CGIData} {
+			for k, value := range v {
+				v[k] = scrubValue(value, patterns)
+			}
+		}
+	}
+	if c := n.Client; c != nil {
+		c.UserAgent = scrubValue(c.UserAgent, patterns)
+		c.Referer = scrubValue(c.Referer, patterns)
+	}
+	for i, a := range n.attachments {
+		if utf8.Valid(a.data) {
+			n.attachments[i].data = []byte(scrubValue(string(a.data), patterns))
+		}
+	}
+}
+
+func scrubValue(s string, patterns []*regexp.Regexp) string {
+	for _, pattern := range patterns {
+		if pattern == PAN {
+			s = pattern.ReplaceAllStringFunc(s, func(match string) string {
+				if luhn(match) {
+					return filtered
+				}
+				return match
+			})
+		} else {
+			s = pattern.ReplaceAllString(s, filtered)
+		}
+	}
+	return s
+}
+
+// luhn checks the digits of the number, ignoring separators, against the
+// Luhn checksum of payment card numbers.
+func luhn(number string) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
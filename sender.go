@@ -0,0 +1,58 @@
+package airbrake
+
+import (
+	"context"
+	"errors"
+)
+
+// Sender delivers the notices that passed the filters, instead of posting
+// them to the endpoint, e.g. to another backend during a migration. The
+// queue, the circuit breaker and the callbacks work the same. A Notifier is
+// itself a Sender, posting to its endpoint with its serializer, so that the
+// notices can be written to both backends with MultiSender:
+//
+//	airbrake.NoticeSender = airbrake.MultiSender(airbrake.NewNotifier(key), sentrySender)
+type Sender interface {
+	Send(*Notice) error
+}
+
+// SenderFunc adapts a function to the Sender interface.
+type SenderFunc func(*Notice) error
+
+func (f SenderFunc) Send(notice *Notice) error { return f(notice) }
+
+// MultiSender returns a Sender sending the notices to each of the senders,
+// which fails if any of them does.
+func MultiSender(senders ...Sender) Sender {
+	return SenderFunc(func(notice *Notice) error {
+		var errs []error
+		for _, sender := range senders {
+			if err := sender.Send(notice); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// Send posts the notice as is, to the endpoint of the notifier, without
+// running the filters, the circuit breaker or the callbacks.
+func (n *Notifier) Send(notice *Notice) error {
+	_, err := n.post(context.Background(), notice)
+	return err
+}
+
+// keyMissing reports whether the notices cannot be sent for lack of an API
+// key, which only posting them to the endpoint requires.
+func (n *Notifier) keyMissing() bool {
+	return n.apiKey == "" && n.sender == nil
+}
+
+// sendWith delivers the notice with the sender, if any, or else posts it.
+func (n *Notifier) sendWith(ctx context.Context, notice *Notice) (Response, error) {
+	if n.sender == nil {
+		return n.post(ctx, notice)
+	}
+	notice.stamp(n.clock.Now())
+	return Response{}, n.sender.Send(notice)
+}
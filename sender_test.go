@@ -0,0 +1,58 @@
+package airbrake
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSender(t *testing.T) {
+	var posted int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted++
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	var sent []*Notice
+	other := SenderFunc(func(notice *Notice) error {
+		sent = append(sent, notice)
+		return nil
+	})
+	n := NewNotifier("key", WithEndpoint("http://127.0.0.1:0"),
+		WithSender(MultiSender(NewNotifier("key", WithEndpoint(server.URL)), other)))
+	if err := n.Notify(errors.New("Boom!")); err != nil {
+		t.Fatal(err)
+	}
+	if posted != 1 || len(sent) != 1 || sent[0].Error.Message != "Boom!" {
+		t.Errorf("expected the notice to be sent to both: %d %v", posted, sent)
+	}
+
+	failing := SenderFunc(func(*Notice) error { return errors.New("backend down") })
+	n = NewNotifier("key", WithSender(MultiSender(other, failing)))
+	if err := n.Notify(errors.New("Boom!")); err == nil || len(sent) != 2 {
+		t.Errorf("expected the failure to be returned: %v", err)
+	}
+	if stats := n.Stats(); stats.Failed != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestSenderWithoutKey(t *testing.T) {
+	var sent []*Notice
+	n := NewNotifier("", WithSender(SenderFunc(func(notice *Notice) error {
+		sent = append(sent, notice)
+		return nil
+	})))
+	if err := n.Notify(errors.New("Boom!")); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Verify(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 || !n.ShouldReport("Boom") {
+		t.Errorf("expected the notices to be sent without a key, got %d", len(sent))
+	}
+}
@@ -0,0 +1,234 @@
+package airbrake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Serializer renders notices as the request body posted to the endpoint.
+type Serializer interface {
+	ContentType() string
+	Serialize(*Notice) ([]byte, error)
+}
+
+var (
+	// XML renders the notifier API version 2 XML document. It is the default.
+	XML Serializer = xmlSerializer{}
+
+	// JSON renders the notifier API version 3 JSON document. The endpoint
+	// must then include the project, e.g.
+	// https://api.airbrake.io/api/v3/projects/<id>/notices, and the API key
+	// of the notice is sent as a bearer token.
+	JSON Serializer = jsonSerializer{}
+
+	// NestedJSON works like JSON, but expands Rails-style parameter keys,
+	// e.g. user[address][city] or tags[0], into nested objects and arrays.
+	NestedJSON Serializer = jsonSerializer{nested: true}
+)
+
+// registry holds the serializers by name, see RegisterSerializer.
+var registry = struct {
+	sync.RWMutex
+	serializers map[string]Serializer
+}{serializers: map[string]Serializer{
+	"xml/v2":         XML,
+	"json/v3":        JSON,
+	"json/v3+nested": NestedJSON,
+}}
+
+// RegisterSerializer makes a serializer available by name, e.g. to the
+// serializer field of the configuration file. The name is the format and
+// the version of the payload it renders, e.g. "xml/v2" or "json/v3", so that
+// a change of format is an explicit change of name. It panics if the name
+// has no version, or is already registered.
+func RegisterSerializer(name string, s Serializer) {
+	if i := strings.Index(name, "/"); i <= 0 || i == len(name)-1 {
+		panic(fmt.Sprintf("airbrake: serializer name %q is not format/version", name))
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.serializers[name]; ok {
+		panic(fmt.Sprintf("airbrake: serializer %q registered twice", name))
+	}
+	registry.serializers[name] = s
+}
+
+// LookupSerializer returns the serializer registered under the name.
+func LookupSerializer(name string) (Serializer, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	s, ok := registry.serializers[name]
+	return s, ok
+}
+
+// Serializers returns the sorted names of the registered serializers.
+func Serializers() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	names := make([]string, 0, len(registry.serializers))
+	for name := range registry.serializers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type xmlSerializer struct{}
+
+func (xmlSerializer) ContentType() string { return "text/xml" }
+
+func (xmlSerializer) Serialize(n *Notice) ([]byte, error) { return n.marshal() }
+
+type jsonSerializer struct {
+	nested bool
+}
+
+func (jsonSerializer) ContentType() string { return "application/json" }
+
+func (s jsonSerializer) Serialize(n *Notice) ([]byte, error) {
+	type frame struct {
+		File     string `json:"file"`
+		Line     int    `json:"line"`
+		Function string `json:"function"`
+		Package  string `json:"package,omitempty"`
+		Receiver string `json:"receiver,omitempty"`
+		Name     string `json:"name,omitempty"`
+	}
+	type errorJSON struct {
+		Type      string  `json:"type"`
+		Message   string  `json:"message"`
+		Backtrace []frame `json:"backtrace"`
+	}
+
+	e := errorJSON{Type: n.Error.Class, Message: n.Error.Message, Backtrace: []frame{}}
+	for _, l := range n.Error.Backtrace {
+		e.Backtrace = append(e.Backtrace, frame{l.File, l.Line, l.Function, l.Package, l.Receiver, l.Name})
+	}
+
+	context := map[string]interface{}{
+		"notifier":      n.Notifier,
+		"environment":   n.ServerEnvironment.EnvironmentName,
+		"hostname":      n.ServerEnvironment.Hostname,
+		"rootDirectory": n.ServerEnvironment.ProjectRoot,
+		"severity":      n.Severity,
+	}
+	if n.User != nil {
+		context["user"] = n.User
+	}
+	if c := n.Client; c != nil {
+		if c.IP != "" {
+			context["userAddr"] = c.IP
+		}
+		if c.UserAgent != "" {
+			context["userAgent"] = c.UserAgent
+		}
+		if c.Referer != "" {
+			context["referer"] = c.Referer
+		}
+	}
+	payload := map[string]interface{}{
+		"errors":  []errorJSON{e},
+		"context": context,
+	}
+	if r := n.Request; r != nil {
+		if r.URL != "" {
+			context["url"] = r.URL
+		}
+		if r.Component != "" {
+			context["component"] = r.Component
+		}
+		if r.Action != "" {
+			context["action"] = r.Action
+		}
+		if s.nested {
+			payload["params"] = expand(r.Params)
+		} else {
+			payload["params"] = r.Params
+		}
+		payload["environment"] = r.CGIData
+		if len(r.Session) > 0 {
+			payload["session"] = r.Session
+		}
+	}
+	return json.Marshal(payload)
+}
+
+// expand nests the params by the bracketed parts of their keys.
+// Objects whose keys are 0..n-1 become arrays.
+func expand(params vars) map[string]interface{} {
+	root := make(map[string]interface{})
+	for key, value := range params {
+		path := strings.Split(strings.Replace(key, "]", "", -1), "[")
+		node := root
+		for _, k := range path[:len(path)-1] {
+			child, ok := node[k].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				node[k] = child
+			}
+			node = child
+		}
+		if _, ok := node[path[len(path)-1]].(map[string]interface{}); !ok {
+			node[path[len(path)-1]] = value
+		}
+	}
+	// The params stay an object, even if their keys are 0..n-1.
+	for k, v := range root {
+		if child, ok := v.(map[string]interface{}); ok {
+			root[k] = arrays(child)
+		}
+	}
+	return root
+}
+
+// arrays converts the objects with keys 0..n-1 to arrays.
+func arrays(node map[string]interface{}) interface{} {
+	for k, v := range node {
+		if child, ok := v.(map[string]interface{}); ok {
+			node[k] = arrays(child)
+		}
+	}
+	list := make([]interface{}, len(node))
+	for k, v := range node {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 || i >= len(list) || list[i] != nil {
+			return node
+		}
+		list[i] = v
+	}
+	if len(list) == 0 {
+		return node
+	}
+	return list
+}
+
+// TemplateSerializer renders notices with the template, which is executed
+// with the *Notice, e.g. for collectors that need extra elements.
+func TemplateSerializer(t *template.Template, contentType string) Serializer {
+	return templateSerializer{t, contentType}
+}
+
+type templateSerializer struct {
+	template    *template.Template
+	contentType string
+}
+
+func (s templateSerializer) ContentType() string { return s.contentType }
+
+func (s templateSerializer) Serialize(n *Notice) ([]byte, error) {
+	var b bytes.Buffer
+	if err := s.template.Execute(&b, n); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,132 @@
+package airbrake
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestJSONSerializer(t *testing.T) {
+	request, _ := http.NewRequest("GET", "/query?q=x", nil)
+	notice := defaultNotifier().newNotice(errors.New("Boom!"), request, 0)
+	notice.Severity = SeverityWarning
+
+	b, err := JSON.Serialize(notice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Errors []struct {
+			Type, Message string
+			Backtrace     []struct{ Function, Package, Name string }
+		}
+		Context map[string]interface{}
+		Params  map[string]string
+	}
+	if err := json.Unmarshal(b, &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(payload.Errors) != 1 || payload.Errors[0].Message != "Boom!" || len(payload.Errors[0].Backtrace) == 0 {
+		t.Fatalf("unexpected errors: %+v", payload.Errors)
+	}
+	if top := payload.Errors[0].Backtrace[0]; top.Package != "testing" || top.Name != "tRunner" {
+		t.Errorf("unexpected frame: %+v", top)
+	}
+	if payload.Context["url"] != "/query?q=x" || payload.Context["severity"] != "warning" {
+		t.Errorf("unexpected context: %v", payload.Context)
+	}
+	if payload.Params["q"] != "x" {
+		t.Errorf("unexpected params: %v", payload.Params)
+	}
+}
+
+func TestTemplateSerializer(t *testing.T) {
+	s := TemplateSerializer(template.Must(template.New("").Parse(`{{ .Error.Class }}: {{ .Error.Message }}`)), "text/plain")
+	b, err := s.Serialize(defaultNotifier().newNotice(errors.New("Boom!"), nil, 0))
+	if err != nil || string(b) != "*errors.errorString: Boom!" {
+		t.Errorf("unexpected payload: %s %v", b, err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	expanded := expand(vars{"user[name]": "x", "user[address][city]": "y", "tags[0]": "a", "tags[1]": "b", "q": "z"})
+	b, _ := json.Marshal(expanded)
+	if string(b) != `{"q":"z","tags":["a","b"],"user":{"address":{"city":"y"},"name":"x"}}` {
+		t.Errorf("unexpected params: %s", b)
+	}
+
+	expanded = expand(vars{"0": "x", "1": "y"})
+	b, _ = json.Marshal(expanded)
+	if string(b) != `{"0":"x","1":"y"}` {
+		t.Errorf("unexpected params: %s", b)
+	}
+}
+
+func BenchmarkSerialize(b *testing.B) {
+	request, _ := http.NewRequest("GET", "/users?id=1&name=Jane", nil)
+	request.Header.Set("User-Agent", "bench")
+	notice := NewNotifier("key").newNotice(errors.New("Boom!"), request, 0)
+
+	for name, serializer := range map[string]Serializer{"XML": XML, "JSON": JSON} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				serializer.Serialize(notice)
+			}
+		})
+	}
+}
+
+func TestGolden(t *testing.T) {
+	for _, name := range Serializers() {
+		s, _ := LookupSerializer(name)
+		dir := filepath.Join("testdata", "golden", strings.Replace(name, "/", "-", -1))
+		if err := CheckGolden(s, dir, os.Getenv("AIRBRAKE_UPDATE_GOLDEN") != ""); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestCheckGolden(t *testing.T) {
+	dir := t.TempDir()
+	if err := CheckGolden(JSON, dir, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckGolden(JSON, dir, false); err != nil {
+		t.Errorf("expected the golden files to match, got: %v", err)
+	}
+	err := CheckGolden(XML, dir, false)
+	if err == nil || !strings.Contains(err.Error(), "full.golden: line 1") {
+		t.Errorf("expected a difference, got: %v", err)
+	}
+}
+
+func TestRegisterSerializer(t *testing.T) {
+	s := TemplateSerializer(template.Must(template.New("").Parse(`{{ .Error.Message }}`)), "text/plain")
+	RegisterSerializer("text/v1+test", s)
+	defer func() {
+		registry.Lock()
+		delete(registry.serializers, "text/v1+test")
+		registry.Unlock()
+	}()
+
+	if found, ok := LookupSerializer("text/v1+test"); !ok || found != s {
+		t.Errorf("expected the serializer to be registered, got: %v", Serializers())
+	}
+	for _, name := range []string{"text/v1+test", "text"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected registering %q to panic", name)
+				}
+			}()
+			RegisterSerializer(name, s)
+		}()
+	}
+}
@@ -0,0 +1,44 @@
+package airbrake
+
+import "errors"
+
+// severityError attaches a severity to an error.
+type severityError struct {
+	error
+	severity string
+}
+
+func (e *severityError) Unwrap() error { return e.error }
+
+// Critical marks the error as critical when it is reported, so the severity
+// can be decided where the error is created.
+func Critical(e error) error { return withSeverity(e, SeverityCritical) }
+
+// Warning marks the error as a warning when it is reported.
+func Warning(e error) error { return withSeverity(e, SeverityWarning) }
+
+// Info marks the error as informational when it is reported.
+func Info(e error) error { return withSeverity(e, SeverityInfo) }
+
+func withSeverity(e error, severity string) error {
+	if e == nil {
+		return nil
+	}
+	return &severityError{e, severity}
+}
+
+// severityOf returns the severity the error was marked with, if any, and the
+// error to report: without the marking, if it is the outermost error.
+func severityOf(e error) (string, error) {
+	var marked *severityError
+	if !errors.As(e, &marked) {
+		return "", e
+	}
+	for {
+		s, ok := e.(*severityError)
+		if !ok {
+			return marked.severity, e
+		}
+		e = s.error
+	}
+}
@@ -0,0 +1,29 @@
+package airbrake
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSeverityWrappers(t *testing.T) {
+	n := NewNotifier("key")
+	for _, sample := range []struct {
+		e               error
+		severity, class string
+	}{
+		{Warning(errors.New("Boom!")), SeverityWarning, "*errors.errorString"},
+		{Critical(errors.New("Boom!")), SeverityCritical, "*errors.errorString"},
+		{fmt.Errorf("job: %w", Info(errors.New("Boom!"))), SeverityInfo, "*fmt.wrapError"},
+		{errors.New("Boom!"), SeverityError, "*errors.errorString"},
+	} {
+		notice := n.newNotice(sample.e, nil, 0)
+		if notice.Severity != sample.severity || notice.Error.Class != sample.class || notice.Error.Message != sample.e.Error() {
+			t.Errorf("%v unexpected notice: %s %s", sample.e, notice.Severity, notice.Error.Class)
+		}
+	}
+
+	if Warning(nil) != nil {
+		t.Error("expected nil errors to stay nil")
+	}
+}
@@ -0,0 +1,25 @@
+package airbrake
+
+import (
+	"context"
+	"net/http"
+)
+
+// SnapshotRequest copies what notices report of the request, so errors
+// occurring after its connection was hijacked, e.g. upgraded to a websocket,
+// or after the handler returned, can still be reported along with it:
+//
+//	snapshot := airbrake.SnapshotRequest(r)
+//	conn, err := upgrader.Upgrade(w, r, nil)
+//	...
+//	airbrake.Error(err, snapshot)
+//
+// Like Error, it parses the form, consuming the body of form posts. The
+// snapshot keeps the values of the request context, but is not canceled
+// with it.
+func SnapshotRequest(r *http.Request) *http.Request {
+	parseForm(r)
+	snapshot := r.Clone(context.WithoutCancel(r.Context()))
+	snapshot.Body = http.NoBody
+	return snapshot
+}
@@ -0,0 +1,30 @@
+package airbrake
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRequest(t *testing.T) {
+	r := httptest.NewRequest("POST", "/chat?room=42", strings.NewReader("name=Jane"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("User-Agent", "browser")
+	snapshot := SnapshotRequest(r)
+
+	// Hijacking servers reuse or tear down the request.
+	r.Header = nil
+	r.Form = nil
+	r.URL.RawQuery = ""
+	r.RequestURI = ""
+
+	notice := NewNotifier("key").newNotice(errors.New("Boom!"), snapshot, 0)
+	req := notice.Request
+	if req.URL != "/chat?room=42" || req.Params["room"] != "42" || req.Params["name"] != "Jane" {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if notice.Client == nil || notice.Client.UserAgent != "browser" {
+		t.Errorf("unexpected client: %+v", notice.Client)
+	}
+}
@@ -0,0 +1,149 @@
+// Package sqldriver wraps database/sql drivers to report the failed queries
+// to airbrake, and to record the slow ones, so that they are added to the
+// notices of the request that ran them.
+//
+// Example:
+//
+//	sql.Register("airbrake-postgres", sqldriver.Wrap(&pq.Driver{}, sqldriver.Options{
+//	    SlowQuery: 100 * time.Millisecond,
+//	}))
+//	airbrake.ExtractFromContext(sqldriver.Extract)
+//	http.Handle("/", sqldriver.Handler(app))
+package sqldriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/tobi/airbrake-go"
+)
+
+// Options configures the wrapped driver.
+type Options struct {
+	// Notifier reports the failed queries. If nil, the package-level
+	// functions are used.
+	Notifier *airbrake.Notifier
+
+	// SlowQuery is the duration above which queries are recorded.
+	// Zero records none.
+	SlowQuery time.Duration
+
+	// ReportErrors reports the failed queries as notices. Otherwise, they
+	// are only recorded, like the slow queries.
+	ReportErrors bool
+}
+
+// QueryError is reported for a failed query. Query is scrubbed of literals.
+type QueryError struct {
+	Query    string
+	Duration time.Duration
+	Args     int
+	Err      error
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s (%s, %d args): %s", e.Query, e.Duration.Round(time.Microsecond), e.Args, e.Err)
+}
+
+func (e *QueryError) Unwrap() error { return e.Err }
+
+var literals = regexp.MustCompile(`'(?:[^']|'')*'|\$?\b\d+(?:\.\d+)?\b`)
+
+// Scrub replaces the string and number literals of the query with ?,
+// so that no data ends up in the reports. Placeholders like $1 are kept.
+func Scrub(query string) string {
+	return literals.ReplaceAllStringFunc(query, func(literal string) string {
+		if literal[0] == '$' {
+			return literal
+		}
+		return "?"
+	})
+}
+
+// Wrap returns a driver which records the queries run on the connections
+// opened by d.
+func Wrap(d driver.Driver, options Options) driver.Driver {
+	return &wrappedDriver{d, options}
+}
+
+// WrapConnector works like Wrap, for use with sql.OpenDB.
+func WrapConnector(c driver.Connector, options Options) driver.Connector {
+	return &connector{c, &wrappedDriver{c.Driver(), options}}
+}
+
+type wrappedDriver struct {
+	driver.Driver
+	options Options
+}
+
+func (d *wrappedDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{c, d}, nil
+}
+
+func (d *wrappedDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.Driver.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &connector{c, d}, nil
+	}
+	return &connector{dsnConnector{name, d.Driver}, d}, nil
+}
+
+// record times the query, and records it if it failed or was slow.
+func (d *wrappedDriver) record(ctx context.Context, query string, args int, start time.Time, err error) {
+	elapsed := time.Since(start)
+	if err == driver.ErrSkip || err == io.EOF || errors.Is(err, context.Canceled) {
+		err = nil
+	}
+	if err == nil && (d.options.SlowQuery <= 0 || elapsed < d.options.SlowQuery) {
+		return
+	}
+
+	q := &QueryError{Scrub(query), elapsed, args, err}
+	if t, ok := ctx.Value(trailKey{}).(*trail); ok {
+		t.add(q)
+	}
+	if err != nil && d.options.ReportErrors {
+		if d.options.Notifier != nil {
+			d.options.Notifier.NotifyContext(ctx, q)
+		} else {
+			airbrake.NotifyContext(ctx, q)
+		}
+	}
+}
+
+type connector struct {
+	driver.Connector
+	driver *wrappedDriver
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	wrapped, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{wrapped, c.driver}, nil
+}
+
+func (c *connector) Driver() driver.Driver { return c.driver }
+
+// dsnConnector opens connections for drivers without a driver.Connector.
+type dsnConnector struct {
+	name   string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.name) }
+
+func (c dsnConnector) Driver() driver.Driver { return c.driver }
@@ -0,0 +1,89 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tobi/airbrake-go"
+)
+
+func TestScrub(t *testing.T) {
+	for query, expected := range map[string]string{
+		"SELECT * FROM users WHERE id = 42":                   "SELECT * FROM users WHERE id = ?",
+		"SELECT * FROM users WHERE email = 'bob@example.com'": "SELECT * FROM users WHERE email = ?",
+		"UPDATE t2 SET name = 'O''Brien', score = 1.5":        "UPDATE t2 SET name = ?, score = ?",
+		"SELECT * FROM users WHERE id = $1":                   "SELECT * FROM users WHERE id = $1",
+	} {
+		if scrubbed := Scrub(query); scrubbed != expected {
+			t.Errorf("%s expected: %s got: %s", query, expected, scrubbed)
+		}
+	}
+}
+
+// fakeDriver fails the queries on the missing table.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+func (fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if strings.Contains(query, "missing") {
+		return nil, errors.New(`relation "missing" does not exist`)
+	}
+	return fakeRows{}, nil
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string              { return []string{"id"} }
+func (fakeRows) Close() error                   { return nil }
+func (fakeRows) Next(dest []driver.Value) error { return io.EOF }
+
+func TestWrap(t *testing.T) {
+	var reported []*airbrake.Notice
+	n := airbrake.NewNotifier("key", airbrake.WithFilter(func(notice *airbrake.Notice) *airbrake.Notice {
+		reported = append(reported, notice)
+		return nil
+	}))
+	n.ExtractFromContext(Extract)
+
+	wrapped := Wrap(fakeDriver{}, Options{Notifier: n, SlowQuery: time.Nanosecond, ReportErrors: true})
+	connector, err := wrapped.(driver.DriverContext).OpenConnector("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	ctx := WithQueries(context.Background())
+	rows, err := db.QueryContext(ctx, "SELECT id FROM users WHERE name = 'bob'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+	if _, err := db.QueryContext(ctx, "SELECT id FROM missing WHERE id = $1", 42); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if len(reported) != 1 || !strings.HasPrefix(reported[0].Error.Message, "SELECT id FROM missing WHERE id = $1 (") {
+		t.Fatalf("unexpected notices: %v", reported)
+	}
+
+	n.NotifyContext(ctx, errors.New("Boom!"))
+	params := reported[1].Request.Params
+	if !strings.HasPrefix(params["query.0"], "SELECT id FROM users WHERE name = ? (") ||
+		params["query.1.error"] != `relation "missing" does not exist` {
+		t.Errorf("unexpected params: %v", params)
+	}
+}
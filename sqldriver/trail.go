@@ -0,0 +1,76 @@
+package sqldriver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tobi/airbrake-go"
+)
+
+// maxQueries bounds how many queries are recorded per context.
+const maxQueries = 20
+
+// trailKey is the context key of the trail.
+type trailKey struct{}
+
+// trail collects the slow and failed queries run with a context.
+type trail struct {
+	mu      sync.Mutex
+	queries []*QueryError
+	omitted int
+}
+
+func (t *trail) add(q *QueryError) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.queries) < maxQueries {
+		t.queries = append(t.queries, q)
+	} else {
+		t.omitted++
+	}
+}
+
+// WithQueries returns a context in which the slow and failed queries are
+// recorded, up to 20 of them.
+func WithQueries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, trailKey{}, new(trail))
+}
+
+// Handler "middleware".
+// Wraps the http handler so that the slow and failed queries of its requests
+// are recorded, provided that they are run with the context of the request.
+func Handler(app http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app.ServeHTTP(w, r.WithContext(WithQueries(r.Context())))
+	})
+}
+
+// Extract adds the queries recorded in the context to the notice, e.g.
+//
+//	query.0: SELECT * FROM users WHERE id = ? (250ms, 1 args)
+//	query.1.error: pq: relation "invoices" does not exist
+//
+// It is meant to be registered with airbrake.ExtractFromContext.
+func Extract(ctx context.Context, notice *airbrake.Notice) {
+	t, ok := ctx.Value(trailKey{}).(*trail)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	params := notice.Request.Params
+	for i, q := range t.queries {
+		key := fmt.Sprintf("query.%d", i)
+		params[key] = fmt.Sprintf("%s (%s, %d args)", q.Query, q.Duration.Round(time.Microsecond), q.Args)
+		if q.Err != nil {
+			params[key+".error"] = q.Err.Error()
+		}
+	}
+	if t.omitted > 0 {
+		params["query.omitted"] = fmt.Sprint(t.omitted)
+	}
+}
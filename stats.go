@@ -0,0 +1,111 @@
+package airbrake
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats counts the notices handled by a Notifier.
+type Stats struct {
+	Sent           uint64 // delivered to the endpoint
+	Failed         uint64 // delivery failed
+	ShortCircuited uint64 // dropped while the circuit breaker was open
+	Dropped        uint64 // dropped because the queue was full
+	Ignored        uint64 // dropped as client disconnects
+	DryRun         uint64 // built but not sent, in dry-run mode
+	Suppressed     uint64 // dropped as duplicates, see DedupWindow
+	Sampled        uint64 // dropped by the TimeoutSampleRate
+	OverQuota      uint64 // dropped once the HourlyQuota was exceeded
+	QueueDepth     int64  // waiting in the queue
+}
+
+// Histogram is a distribution of durations. It is cumulative, like the
+// histograms of Prometheus: Counts[i] observations took at most Buckets[i].
+type Histogram struct {
+	Buckets []time.Duration
+	Counts  []uint64
+	Count   uint64
+	Sum     time.Duration
+}
+
+// latencyBuckets are the upper bounds of the delivery latency histogram.
+var latencyBuckets = [...]time.Duration{
+	5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond,
+	50 * time.Millisecond, 100 * time.Millisecond, 250 * time.Millisecond,
+	500 * time.Millisecond, time.Second, 2500 * time.Millisecond,
+	5 * time.Second, 10 * time.Second,
+}
+
+// stats holds the live counters behind Stats.
+type stats struct {
+	sent, failed, shortCircuited, dropped, ignored, dryRun, suppressed, sampled, overQuota uint64
+
+	// latency counts the deliveries per bucket, the last one being for
+	// those slower than all the buckets, and latencySum their total
+	// duration in nanoseconds.
+	latency    [len(latencyBuckets) + 1]uint64
+	latencySum uint64
+}
+
+func (s *stats) snapshot() Stats {
+	return Stats{
+		Sent:           atomic.LoadUint64(&s.sent),
+		Failed:         atomic.LoadUint64(&s.failed),
+		ShortCircuited: atomic.LoadUint64(&s.shortCircuited),
+		Dropped:        atomic.LoadUint64(&s.dropped),
+		Ignored:        atomic.LoadUint64(&s.ignored),
+		DryRun:         atomic.LoadUint64(&s.dryRun),
+		Suppressed:     atomic.LoadUint64(&s.suppressed),
+		Sampled:        atomic.LoadUint64(&s.sampled),
+		OverQuota:      atomic.LoadUint64(&s.overQuota),
+	}
+}
+
+// observe records the latency of a delivery.
+func (s *stats) observe(d time.Duration) {
+	i := 0
+	for i < len(latencyBuckets) && d > latencyBuckets[i] {
+		i++
+	}
+	atomic.AddUint64(&s.latency[i], 1)
+	atomic.AddUint64(&s.latencySum, uint64(d))
+}
+
+func (s *stats) histogram() Histogram {
+	h := Histogram{
+		Buckets: latencyBuckets[:],
+		Counts:  make([]uint64, len(latencyBuckets)),
+		Sum:     time.Duration(atomic.LoadUint64(&s.latencySum)),
+	}
+	for i := range s.latency {
+		h.Count += atomic.LoadUint64(&s.latency[i])
+		if i < len(h.Counts) {
+			h.Counts[i] = h.Count
+		}
+	}
+	return h
+}
+
+// CurrentStats returns the counters of the package-level functions.
+func CurrentStats() Stats {
+	return defaultNotifier().Stats()
+}
+
+// Stats returns the counters of the notifier.
+func (n *Notifier) Stats() Stats {
+	s := n.state.stats.snapshot()
+	s.QueueDepth = atomic.LoadInt64(&n.state.queue.pending)
+	return s
+}
+
+// CurrentLatency returns the delivery latencies of the package-level
+// functions.
+func CurrentLatency() Histogram {
+	return defaultNotifier().Latency()
+}
+
+// Latency returns the distribution of the time taken to post the notices,
+// whether or not the delivery succeeded.
+func (n *Notifier) Latency() Histogram {
+	return n.state.stats.histogram()
+}
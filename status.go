@@ -0,0 +1,101 @@
+package airbrake
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// StatusSeverity maps a response status code to a severity:
+// 5xx responses are errors, 4xx responses are warnings.
+// It returns "" for other status codes.
+func StatusSeverity(status int) string {
+	switch {
+	case status >= 500:
+		return SeverityError
+	case status >= 400:
+		return SeverityWarning
+	}
+	return ""
+}
+
+// StatusError is reported by StatusHandler for error responses.
+type StatusError struct {
+	Method string
+	URL    string
+	Status int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s responded %d %s", e.Method, e.URL, e.Status, http.StatusText(e.Status))
+}
+
+// StatusHandler "middleware".
+// Wraps the http handler so that 5xx responses are reported to airbrake,
+// even if no panic occurred. 4xx responses are reported as warnings
+// when NotifyClientErrors is set.
+func StatusHandler(app http.Handler) http.Handler {
+	return statusHandler(defaultNotifier, app)
+}
+
+// StatusHandler works like the package-level StatusHandler.
+func (n *Notifier) StatusHandler(app http.Handler) http.Handler {
+	return statusHandler(func() *Notifier { return n }, app)
+}
+
+func statusHandler(notifier func() *Notifier, app http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		app.ServeHTTP(recorder, r)
+
+		n := notifier()
+		severity := StatusSeverity(recorder.status)
+		if severity == "" || (severity == SeverityWarning && !n.notifyClientErrors) {
+			return
+		}
+		notice := n.newNotice(&StatusError{r.Method, r.URL.Path, recorder.status}, r, 0)
+		notice.Severity = severity
+		n.send(notice)
+	})
+}
+
+// statusRecorder remembers the status code written by the handler, and
+// whether it started the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status  int
+	written bool
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+	// Informational responses, e.g. 103 Early Hints, precede the response.
+	w.written = w.written || status >= 200
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush flushes the underlying response writer, if it can.
+func (w *statusRecorder) Flush() {
+	w.written = true
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying response writer, for http.ResponseController.
+func (w *statusRecorder) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// Hijack hijacks the underlying connection, if the response writer can.
+func (w *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.written = true
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
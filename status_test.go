@@ -0,0 +1,59 @@
+package airbrake
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusSeverity(t *testing.T) {
+	for status, severity := range map[int]string{200: "", 302: "", 404: "warning", 503: "error"} {
+		if result := StatusSeverity(status); result != severity {
+			t.Errorf("%d expected: %s got: %s", status, severity, result)
+		}
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	var reported []*Notice
+	capture := capturing(&reported)
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		} else if r.URL.Path == "/broken" {
+			http.Error(w, "broken", http.StatusBadGateway)
+		}
+	})
+
+	for _, n := range []*Notifier{NewNotifier("key", capture), NewNotifier("key", capture, WithClientErrors(true))} {
+		for _, path := range []string{"/", "/missing", "/broken"} {
+			n.StatusHandler(app).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		}
+	}
+
+	if len(reported) != 3 {
+		t.Fatalf("expected 3 notices, got: %d", len(reported))
+	}
+	if reported[0].Error.Message != "GET /broken responded 502 Bad Gateway" || reported[0].Severity != SeverityError {
+		t.Errorf("unexpected notice: %+v", reported[0])
+	}
+	if reported[1].Severity != SeverityWarning {
+		t.Errorf("unexpected notice: %+v", reported[1])
+	}
+}
+
+func TestStatusHandlerFlush(t *testing.T) {
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("expected an http.Flusher")
+		}
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	recorder := httptest.NewRecorder()
+	NewNotifier("key").StatusHandler(app).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	if !recorder.Flushed {
+		t.Error("expected the response to be flushed")
+	}
+}
@@ -0,0 +1,36 @@
+package airbrake
+
+// NotifyWithTags reports the error with the tags, e.g. the team or feature
+// it belongs to, on top of the Tags setting. See Notifier.NotifyWithTags.
+func NotifyWithTags(e error, tags map[string]string) error {
+	n := defaultNotifier()
+	notice := n.newNotice(e, nil, 0)
+	addTags(notice, tags)
+	return n.send(notice)
+}
+
+// NotifyWithTags reports the error with the tags, which override the default
+// tags of the notifier. Tags are sent as the tag.<name> params, so that saved
+// searches can filter on them (in Errbit), e.g. for tag.team:payments.
+//
+// Example:
+//
+//	n.NotifyWithTags(err, map[string]string{"team": "payments", "region": "eu"})
+func (n *Notifier) NotifyWithTags(e error, tags map[string]string) error {
+	notice := n.newNotice(e, nil, 0)
+	addTags(notice, tags)
+	return n.send(notice)
+}
+
+// addTags adds the tags, with a name and value, to the params of the notice.
+func addTags(notice *Notice, tags map[string]string) {
+	if len(tags) == 0 {
+		return
+	}
+	params := notice.request().Params
+	for name, value := range tags {
+		if name != "" && value != "" {
+			params["tag."+name] = value
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package airbrake
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNotifyWithTags(t *testing.T) {
+	var reported []*Notice
+	config := &Config{Tags: map[string]string{"region": "eu"}}
+	n := NewNotifier("key", append([]Option{WithTags(map[string]string{"team": "platform", "service": "billing"})},
+		append(config.Options(), capturing(&reported))...)...)
+
+	n.NotifyWithTags(errors.New("Boom!"), map[string]string{"team": "payments", "feature": "", "": "x"})
+	n.Notify(errors.New("Boom!"))
+
+	if len(reported) != 2 {
+		t.Fatalf("expected 2 notices, got: %d", len(reported))
+	}
+	params := reported[0].Request.Params
+	if len(params) != 3 || params["tag.team"] != "payments" || params["tag.service"] != "billing" || params["tag.region"] != "eu" {
+		t.Errorf("unexpected params: %v", params)
+	}
+	if params := reported[1].Request.Params; params["tag.team"] != "platform" {
+		t.Errorf("unexpected params: %v", params)
+	}
+}
@@ -0,0 +1,48 @@
+package airbrake
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// timeout reports whether the error is a timeout, like those of net.Error,
+// context.DeadlineExceeded or os.ErrDeadlineExceeded.
+func timeout(e error) bool {
+	var t interface{ Timeout() bool }
+	return errors.As(e, &t) && t.Timeout()
+}
+
+// sampleTimeout reports whether the timeout notice is to be dropped by the
+// sample rate. Otherwise, it downgrades its severity, if configured and not
+// set explicitly, e.g. with Critical.
+func (n *Notifier) sampleTimeout(notice *Notice) bool {
+	if n.timeoutSampleRate < 1 && n.rand.Float64() >= n.timeoutSampleRate {
+		return true
+	}
+	if n.timeoutSeverity != "" && notice.Severity == SeverityError {
+		notice.Severity = n.timeoutSeverity
+	}
+	return false
+}
+
+// addDeadline adds the deadline of the context, how long ago it expired, and
+// the cause of its cancellation, as the context.deadline, context.expired_ago
+// and context.cause params, if the error is a timeout or cancellation, or the
+// context is done.
+func (n *Notifier) addDeadline(ctx context.Context, notice *Notice) {
+	e := notice.err
+	if ctx.Err() == nil && !timeout(e) && !errors.Is(e, context.Canceled) {
+		return
+	}
+	params := notice.request().Params
+	if deadline, ok := ctx.Deadline(); ok {
+		params["context.deadline"] = deadline.UTC().Format(time.RFC3339Nano)
+		if now := n.clock.Now(); now.After(deadline) {
+			params["context.expired_ago"] = now.Sub(deadline).Round(time.Millisecond).String()
+		}
+	}
+	if cause := context.Cause(ctx); cause != nil && cause != ctx.Err() {
+		params["context.cause"] = cause.Error()
+	}
+}
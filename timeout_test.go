@@ -0,0 +1,74 @@
+package airbrake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	for _, sample := range []struct {
+		err error
+		out bool
+	}{
+		{context.DeadlineExceeded, true},
+		{fmt.Errorf("fetching rates: %w", os.ErrDeadlineExceeded), true},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{IsTimeout: true}}, true},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, false},
+		{errors.New("timeout"), false},
+	} {
+		if result := timeout(sample.err); result != sample.out {
+			t.Errorf("%v expected: %v got: %v", sample.err, sample.out, result)
+		}
+	}
+}
+
+func TestTimeoutSeverity(t *testing.T) {
+	var severities []string
+	options := []Option{WithDryRun(true), WithFilter(func(notice *Notice) *Notice {
+		severities = append(severities, notice.Severity)
+		return notice
+	})}
+
+	n := NewNotifier("key", append(options, WithTimeouts(SeverityWarning, 1))...)
+	n.Notify(context.DeadlineExceeded)
+	n.Notify(Critical(context.DeadlineExceeded))
+	n.Notify(errors.New("Boom!"))
+	if len(severities) != 3 || severities[0] != SeverityWarning || severities[1] != SeverityCritical || severities[2] != SeverityError {
+		t.Errorf("unexpected severities: %v", severities)
+	}
+
+	n = NewNotifier("key", append(options, WithTimeouts("", 0.5), WithRand(fixedRand(0.75)))...)
+	n.Notify(context.DeadlineExceeded)
+	n.Notify(errors.New("Boom!"))
+	if stats := n.Stats(); stats.Sampled != 1 || stats.DryRun != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestDeadlineParams(t *testing.T) {
+	clock := &fakeClock{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	n := NewNotifier("key", WithClock(clock))
+
+	ctx, cancel := context.WithDeadline(context.Background(), clock.now.Add(-1500*time.Millisecond))
+	defer cancel()
+	params := n.contextNotice(ctx, ctx.Err(), nil).Request.Params
+	if params["context.deadline"] != "2020-01-02T03:04:03.5Z" || params["context.expired_ago"] != "1.5s" || params["context.cause"] != "" {
+		t.Errorf("unexpected params: %v", params)
+	}
+
+	ctx, cancelCause := context.WithCancelCause(context.Background())
+	cancelCause(errors.New("client went away"))
+	params = n.contextNotice(ctx, fmt.Errorf("querying: %w", ctx.Err()), nil).Request.Params
+	if params["context.cause"] != "client went away" || params["context.deadline"] != "" {
+		t.Errorf("unexpected params: %v", params)
+	}
+
+	if notice := n.contextNotice(context.Background(), errors.New("Boom!"), nil); notice.Request != nil {
+		t.Errorf("unexpected params: %v", notice.Request.Params)
+	}
+}
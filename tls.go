@@ -0,0 +1,60 @@
+package airbrake
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// tlsClients caches the clients built for a TLS config, so that their
+// connections are reused across the notifiers of the package-level functions.
+var tlsClients sync.Map // tlsClientKey → *http.Client
+
+type tlsClientKey struct {
+	client *http.Client
+	config *tls.Config
+}
+
+// httpClient returns the client posting to the endpoint: the configured one,
+// or, with a TLS config, a copy of it whose transport uses the TLS config.
+// A transport that cannot be copied is kept, see TLSConfig.
+func (n *Notifier) httpClient() *http.Client {
+	if n.tlsConfig == nil {
+		return n.client
+	}
+	key := tlsClientKey{n.client, n.tlsConfig}
+	if client, ok := tlsClients.Load(key); ok {
+		return client.(*http.Client)
+	}
+
+	base, ok := n.client.Transport.(*http.Transport)
+	if n.client.Transport == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	} else if !ok {
+		if _, loaded := tlsClients.LoadOrStore(key, n.client); !loaded {
+			log.Printf("Airbrake: the TLS config is ignored, the transport of the HTTP client is a %T, not an *http.Transport", n.client.Transport)
+		}
+		return n.client
+	}
+	transport := base.Clone()
+	transport.TLSClientConfig = n.tlsConfig
+	client := *n.client
+	client.Transport = transport
+	cached, _ := tlsClients.LoadOrStore(key, &client)
+	return cached.(*http.Client)
+}
+
+// setBasicAuth adds the basic-auth credentials to the delivery request, if any.
+func (n *Notifier) setBasicAuth(request *http.Request) {
+	if n.basicAuthUser != "" || n.basicAuthPassword != "" {
+		request.SetBasicAuth(n.basicAuthUser, n.basicAuthPassword)
+	}
+}
+
+// RootCAs returns a TLS config trusting the certificates of the pool, e.g. the
+// internal CA of a self-hosted Errbit, for use as the TLSConfig setting.
+func RootCAs(pool *x509.CertPool) *tls.Config {
+	return &tls.Config{RootCAs: pool}
+}
@@ -0,0 +1,65 @@
+package airbrake
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBasicAuthAndRootCAs(t *testing.T) {
+	var user, password string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ = r.BasicAuth()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	// Without the CA of the server, the delivery fails.
+	n := NewNotifier("key", WithEndpoint(server.URL))
+	if err := n.Notify(errors.New("Boom!")); err == nil {
+		t.Fatal("expected a certificate error")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	n = NewNotifier("key", WithEndpoint(server.URL), WithTLSConfig(RootCAs(pool)), WithBasicAuth("errbit", "s3cret"))
+	if err := n.Notify(errors.New("Boom!")); err != nil {
+		t.Fatal(err)
+	}
+	if user != "errbit" || password != "s3cret" {
+		t.Errorf("unexpected credentials: %q %q", user, password)
+	}
+	if n.httpClient() != n.httpClient() || http.DefaultClient.Transport != nil {
+		t.Error("expected a cached copy of the client")
+	}
+}
+
+// countingTransport wraps a transport, like instrumentation does.
+type countingTransport struct {
+	http.RoundTripper
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.requests++
+	return t.RoundTripper.RoundTrip(r)
+}
+
+func TestTLSConfigWrappedTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{RoundTripper: http.DefaultTransport}
+	client := &http.Client{Transport: transport}
+	n := NewNotifier("key", WithEndpoint(server.URL), WithHTTPClient(client), WithTLSConfig(RootCAs(x509.NewCertPool())))
+	if err := n.Notify(errors.New("Boom!")); err != nil {
+		t.Fatal(err)
+	}
+	if n.httpClient() != client || transport.requests != 1 {
+		t.Errorf("expected the wrapped transport to be kept, got %d requests", transport.requests)
+	}
+}
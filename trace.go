@@ -0,0 +1,60 @@
+package airbrake
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// TraceContext, if set, extracts the active trace and span IDs from the context
+// passed to ErrorContext. With OpenTelemetry it can be implemented as:
+//
+//	airbrake.TraceContext = func(ctx context.Context) (string, string) {
+//	    sc := trace.SpanContextFromContext(ctx)
+//	    return sc.TraceID().String(), sc.SpanID().String()
+//	}
+//
+// Without it, the IDs are taken from the W3C traceparent header of the request.
+var TraceContext func(ctx context.Context) (traceID, spanID string)
+
+// ErrorContext works like Error, but also attaches the trace and span IDs of
+// the request, so the notice can be linked to the distributed trace.
+func ErrorContext(ctx context.Context, e error, request *http.Request) error {
+	n := defaultNotifier()
+	return n.send(n.contextNotice(ctx, e, request))
+}
+
+// ErrorContext works like the package-level ErrorContext.
+func (n *Notifier) ErrorContext(ctx context.Context, e error, request *http.Request) error {
+	return n.send(n.contextNotice(ctx, e, request))
+}
+
+// addTrace adds the trace_id and span_id params, if any.
+func (n *Notifier) addTrace(ctx context.Context, notice *Notice, request *http.Request) {
+	var traceID, spanID string
+	if n.traceContext != nil && ctx != nil {
+		traceID, spanID = n.traceContext(ctx)
+	}
+	if traceID == "" && request != nil {
+		traceID, spanID = traceparent(request.Header.Get("Traceparent"))
+	}
+	if strings.Trim(traceID, "0") == "" {
+		return
+	}
+
+	params := notice.request().Params
+	params["trace_id"] = traceID
+	if strings.Trim(spanID, "0") != "" {
+		params["span_id"] = spanID
+	}
+}
+
+// traceparent parses a version-traceid-parentid-flags header value.
+// See https://www.w3.org/TR/trace-context/#traceparent-header
+func traceparent(header string) (traceID, spanID string) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", ""
+	}
+	return parts[1], parts[2]
+}
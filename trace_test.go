@@ -0,0 +1,32 @@
+package airbrake
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestTraceparent(t *testing.T) {
+	request, _ := http.NewRequest("GET", "/", nil)
+	request.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	n := defaultNotifier().newNotice(errors.New("Boom!"), request, 0)
+	defaultNotifier().addTrace(context.Background(), n, request)
+	if n.Request.Params["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || n.Request.Params["span_id"] != "00f067aa0ba902b7" {
+		t.Errorf("unexpected params: %v", n.Request.Params)
+	}
+}
+
+func TestTraceContext(t *testing.T) {
+	TraceContext = func(ctx context.Context) (string, string) {
+		return ctx.Value("trace").(string), "span"
+	}
+	defer func() { TraceContext = nil }()
+
+	n := defaultNotifier().newNotice(errors.New("Boom!"), nil, 0)
+	defaultNotifier().addTrace(context.WithValue(context.Background(), "trace", "trace"), n, nil)
+	if n.Request.Params["trace_id"] != "trace" || n.Request.Params["span_id"] != "span" {
+		t.Errorf("unexpected params: %v", n.Request.Params)
+	}
+}
@@ -0,0 +1,126 @@
+package airbrake
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// truncated marks the values that were cut to fit the limits.
+const truncated = "...[truncated]"
+
+// Limits bound the size of notices, so they don't exceed what the
+// endpoint accepts. Zero means unlimited.
+type Limits struct {
+	MessageLength int // of the error message
+	ValueLength   int // of each param and environment value
+	Params        int // number of params and environment values, each
+	PayloadSize   int // of the serialized notice
+
+	// AttachmentSize and AttachmentsSize cap the attachments, each and
+	// in total. Zero means 64KB and 256KB.
+	AttachmentSize  int
+	AttachmentsSize int
+}
+
+// truncate applies the limits to the notice. Truncated notices get
+// a truncated=true param.
+func (n *Notice) truncate(l Limits) {
+	cut := false
+	if l.MessageLength > 0 {
+		n.Error.Message, cut = truncate(n.Error.Message, l.MessageLength)
+	}
+	if n.Request != nil {
+		cut = n.Request.Params.truncate(l) || cut
+		cut = n.Request.Session.truncate(l) || cut
+		cut = n.Request.CGIData.truncate(l) || cut
+	}
+	if cut {
+		n.request().Params["truncated"] = "true"
+	}
+}
+
+// minimize keeps only what identifies the error: its class and message, the
+// top frames of the backtrace, and the URL, component and action of the
+// request. Headers, params, environment, the client, user and host, and
+// attachments are dropped.
+func (n *Notice) minimize(frames int) {
+	if len(n.Error.Backtrace) > frames {
+		n.Error.Backtrace = n.Error.Backtrace[:frames]
+	}
+	if r := n.Request; r != nil {
+		n.Request = &request{URL: r.URL, Component: r.Component, Action: r.Action, Params: make(vars), Session: make(vars), CGIData: make(vars)}
+	}
+	n.Client = nil
+	n.User = nil
+	n.ServerEnvironment.ProjectRoot = ""
+	n.ServerEnvironment.Hostname = ""
+	n.attachments = nil
+}
+
+func (v vars) truncate(l Limits) bool {
+	cut := false
+	if l.Params > 0 && len(v) > l.Params {
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys[l.Params:] {
+			delete(v, k)
+		}
+		cut = true
+	}
+	if l.ValueLength > 0 {
+		for k, value := range v {
+			if value, ok := truncate(value, l.ValueLength); ok {
+				v[k] = value
+				cut = true
+			}
+		}
+	}
+	return cut
+}
+
+// truncate cuts s to at most max bytes, without splitting UTF-8 sequences.
+func truncate(s string, max int) (string, bool) {
+	if len(s) <= max {
+		return s, false
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max] + truncated, true
+}
+
+// serialize renders the notice, shrinking a copy of it if it exceeds the
+// payload size: first the params and environment are dropped, and the
+// backtrace and message are shortened, then it is minimized. It fails if
+// even the minimized notice does not fit.
+func (n *Notifier) serialize(notice *Notice) ([]byte, error) {
+	payload, err := n.serializer.Serialize(notice)
+	if err != nil || n.limits.PayloadSize <= 0 || len(payload) <= n.limits.PayloadSize {
+		return payload, err
+	}
+
+	shrunk := notice.copy()
+	shrunk.request().Params = vars{"truncated": "true"}
+	shrunk.Request.Session = vars{}
+	shrunk.Request.CGIData = vars{}
+	if len(shrunk.Error.Backtrace) > 20 {
+		shrunk.Error.Backtrace = shrunk.Error.Backtrace[:20]
+	}
+	shrunk.Error.Message, _ = truncate(shrunk.Error.Message, 1024)
+	if payload, err = n.serializer.Serialize(shrunk); err != nil || len(payload) <= n.limits.PayloadSize {
+		return payload, err
+	}
+
+	shrunk.minimize(1)
+	shrunk.Request.Params["truncated"] = "true"
+	shrunk.Request.URL, _ = truncate(shrunk.Request.URL, 256)
+	shrunk.Error.Message, _ = truncate(shrunk.Error.Message, 256)
+	if payload, err = n.serializer.Serialize(shrunk); err != nil || len(payload) <= n.limits.PayloadSize {
+		return payload, err
+	}
+	return nil, fmt.Errorf("airbrake: the notice is %d bytes, over the payload size of %d even minimized", len(payload), n.limits.PayloadSize)
+}
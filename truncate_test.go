@@ -0,0 +1,157 @@
+package airbrake
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestTruncate(t *testing.T) {
+	for _, sample := range []struct {
+		in  string
+		max int
+		out string
+	}{
+		{"short", 10, "short"},
+		{"abcdef", 3, "abc" + truncated},
+		{"añb", 2, "a" + truncated},
+	} {
+		if result, _ := truncate(sample.in, sample.max); result != sample.out {
+			t.Errorf("expected: %s got: %s", sample.out, result)
+		}
+	}
+}
+
+func TestNoticeTruncate(t *testing.T) {
+	notice := defaultNotifier().newNotice(errors.New(strings.Repeat("x", 100)), nil, 0)
+	addFields(notice, map[string]interface{}{"a": strings.Repeat("y", 100), "b": "1", "c": "2"})
+	notice.truncate(Limits{MessageLength: 10, ValueLength: 10, Params: 2})
+
+	params := notice.Request.Params
+	if notice.Error.Message != "xxxxxxxxxx"+truncated || params["a"] != "yyyyyyyyyy"+truncated || params["b"] != "1" || params["c"] != "" || params["truncated"] != "true" {
+		t.Errorf("unexpected notice: %s %v", notice.Error.Message, params)
+	}
+}
+
+func TestPayloadSize(t *testing.T) {
+	n := NewNotifier("key", WithLimits(Limits{PayloadSize: 2000}))
+	notice := n.newNotice(errors.New("Boom!"), nil, 0)
+	addFields(notice, map[string]interface{}{"big": strings.Repeat("y", 5000)})
+
+	payload, err := n.serialize(notice)
+	if err != nil || len(payload) > 2000 || !bytes.Contains(payload, []byte(`<var key="truncated">true</var>`)) {
+		t.Errorf("unexpected payload (%d bytes): %v", len(payload), err)
+	}
+	if len(notice.Request.Params["big"]) != 5000 {
+		t.Errorf("the notice was changed: %v", notice.Request.Params)
+	}
+
+	// A backtrace too deep to fit even without the params is minimized.
+	notice = n.newNotice(errors.New(strings.Repeat("z", 5000)), nil, 0)
+	for len(notice.Error.Backtrace) < 20 {
+		notice.Error.Backtrace = append(notice.Error.Backtrace, notice.Error.Backtrace...)
+	}
+	payload, err = n.serialize(notice)
+	if err != nil || len(payload) > 2000 || bytes.Count(payload, []byte("<line ")) != 1 {
+		t.Errorf("unexpected payload (%d bytes): %v", len(payload), err)
+	}
+
+	n = NewNotifier("key", WithLimits(Limits{PayloadSize: 100}))
+	if payload, err = n.serialize(n.newNotice(errors.New("Boom!"), nil, 0)); err == nil {
+		t.Errorf("expected an error, got: %s", payload)
+	}
+}
+
+func TestMinimal(t *testing.T) {
+	n := NewNotifier("key", WithMinimal(2), WithEnvironmentVariables("PATH"))
+	request := httptest.NewRequest("POST", "/checkout?cart=42", strings.NewReader("card=4111"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("User-Agent", "curl/7.64.1")
+	notice := n.newNotice(errors.New("Boom!"), request, 0)
+	notice.Attach("cart.json", []byte(`{"items": 3}`))
+
+	payload, err := n.serialize(n.filter(notice))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notice.Error.Backtrace) != 2 || len(notice.Request.Params) != 0 || len(notice.Request.CGIData) != 0 || notice.Request.URL != "/checkout?cart=42" {
+		t.Errorf("unexpected notice: %+v", notice.Request)
+	}
+	if len(payload) > 1000 {
+		t.Errorf("unexpected payload (%d bytes): %s", len(payload), payload)
+	}
+}
+
+func TestMinimalFields(t *testing.T) {
+	request := httptest.NewRequest("POST", "/checkout?cart=42", strings.NewReader("card=4111"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("User-Agent", "curl/7.64.1")
+	request.Header.Set("Referer", "https://example.com/cart")
+	claims := func(r *http.Request) map[string]interface{} {
+		return map[string]interface{}{"sub": "42", "email": "jane@example.com"}
+	}
+
+	for serializer, expected := range map[Serializer]string{
+		XML:  "action api-key backtrace cgi-data class component environment-name error hostname line message name notice notifier params project-root request server-environment url version",
+		JSON: "context context.environment context.hostname context.notifier context.rootDirectory context.severity context.url environment errors params",
+	} {
+		n := NewNotifier("key", WithMinimal(1), WithSerializer(serializer))
+		n.ExtractUser(claims)
+		notice := n.newNotice(errors.New("Boom!"), request, 0)
+		notice.ServerEnvironment.Hostname = "web-1"
+
+		payload, err := n.serialize(n.filter(notice))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fields := payloadFields(payload, serializer == XML); fields != expected {
+			t.Errorf("unexpected fields: %s", fields)
+		}
+		for _, leaked := range []string{"curl", "example.com", "jane", "web-1", "4111", "192.0.2.1"} {
+			if bytes.Contains(payload, []byte(leaked)) {
+				t.Errorf("unexpected %s in payload: %s", leaked, payload)
+			}
+		}
+	}
+}
+
+// payloadFields lists the element names of an XML payload, or the top level
+// and context keys of a JSON one, sorted.
+func payloadFields(payload []byte, isXML bool) string {
+	seen := make(map[string]bool)
+	if isXML {
+		decoder := xml.NewDecoder(bytes.NewReader(payload))
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				break
+			}
+			if start, ok := token.(xml.StartElement); ok {
+				seen[start.Name.Local] = true
+			}
+		}
+	} else {
+		var document map[string]json.RawMessage
+		json.Unmarshal(payload, &document)
+		for k := range document {
+			seen[k] = true
+		}
+		var context map[string]interface{}
+		json.Unmarshal(document["context"], &context)
+		for k := range context {
+			seen["context."+k] = true
+		}
+	}
+	fields := make([]string, 0, len(seen))
+	for k := range seen {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	return strings.Join(fields, " ")
+}
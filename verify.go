@@ -0,0 +1,42 @@
+package airbrake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Verify checks the configuration of the package-level functions.
+// See Notifier.Verify.
+func Verify(ctx context.Context) error {
+	return defaultNotifier().Verify(ctx)
+}
+
+// Verify sends a test notice and returns a detailed error if the endpoint,
+// or the Sender if set, cannot be reached or rejects it, e.g. because of a
+// wrong API key, so that deployments can fail fast on misconfiguration.
+func (n *Notifier) Verify(ctx context.Context) error {
+	if n.keyMissing() {
+		return apiKeyMissing
+	}
+
+	notice := n.newNotice(errors.New("Verifying the notifier configuration"), nil, 0)
+	notice.Error.Class = "AirbrakeVerify"
+	notice.Severity = SeverityInfo
+
+	response, err := n.sendWith(ctx, notice)
+	switch {
+	case err != nil && n.sender != nil:
+		return fmt.Errorf("airbrake: the sender failed: %w", err)
+	case err == badResponse:
+		return fmt.Errorf("airbrake: %s responded %d %s: %.200s",
+			n.endpoint, response.StatusCode, http.StatusText(response.StatusCode), response.Body)
+	case err != nil && response.StatusCode != 0:
+		return fmt.Errorf("airbrake: %s responded %d %s: %w",
+			n.endpoint, response.StatusCode, http.StatusText(response.StatusCode), err)
+	case err != nil:
+		return fmt.Errorf("airbrake: cannot reach %s: %w", n.endpoint, err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package airbrake
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.RawQuery, "valid") {
+			http.Error(w, "Invalid API key", http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	if err := NewNotifier("key", WithEndpoint(server.URL+"?valid")).Verify(context.Background()); err != nil {
+		t.Error(err)
+	}
+
+	err := NewNotifier("key", WithEndpoint(server.URL)).Verify(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized: Invalid API key") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err = NewNotifier("key", WithEndpoint("http://airbrake.invalid/")).Verify(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "cannot reach") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
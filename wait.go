@@ -0,0 +1,56 @@
+package airbrake
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrDropped is returned by NotifyAndWait when the notice was not sent,
+// because a filter dropped it, it was a client disconnect, or the queue
+// was full.
+var ErrDropped = errors.New("airbrake: the notice was dropped")
+
+// NoticeResult is the outcome of a notice sent with NotifyAndWait.
+type NoticeResult struct {
+	Response Response // how the endpoint answered, if it was reached
+	DryRun   bool     // the notice was built but not sent, in dry-run mode
+}
+
+// outcome is what a waiting caller is told about its notice.
+type outcome struct {
+	result NoticeResult
+	err    error
+}
+
+// NotifyAndWait works like Notifier.NotifyAndWait, with the package-level
+// settings.
+func NotifyAndWait(ctx context.Context, e error) (NoticeResult, error) {
+	return defaultNotifier().NotifyAndWait(ctx, e)
+}
+
+// NotifyAndWait reports the error like Notify, but even with asynchronous
+// delivery, waits until it has been delivered, has failed or was dropped,
+// or the context is done. With SplitErrors, it waits for the first part.
+func (n *Notifier) NotifyAndWait(ctx context.Context, e error) (NoticeResult, error) {
+	notice := n.newNotice(e, nil, 0)
+	notice.done = make(chan outcome, 1)
+	if err := n.send(notice); err == apiKeyMissing {
+		return NoticeResult{}, err
+	}
+
+	select {
+	case o := <-notice.done:
+		return o.result, o.err
+	case <-ctx.Done():
+		return NoticeResult{}, ctx.Err()
+	}
+}
+
+// finish tells the caller waiting for a notice, if any, about its outcome.
+// Only the first outcome is kept, e.g. for the parts of split errors.
+func finish(done chan outcome, result NoticeResult, err error) {
+	select {
+	case done <- outcome{result, err}:
+	default:
+	}
+}
@@ -0,0 +1,42 @@
+package airbrake
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotifyAndWait(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`<notice><id>42</id></notice>`))
+	}))
+	defer server.Close()
+
+	n := NewNotifier("key", WithEndpoint(server.URL), WithQueueSize(10), WithFilter(func(notice *Notice) *Notice {
+		if notice.Error.Message == "Ignored" {
+			return nil
+		}
+		return notice
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := n.NotifyAndWait(ctx, errors.New("Boom!")); err != context.DeadlineExceeded {
+		t.Errorf("expected the wait to time out, got: %v", err)
+	}
+
+	close(release)
+	result, err := n.NotifyAndWait(context.Background(), errors.New("Boom!"))
+	if err != nil || result.Response.ID != "42" {
+		t.Errorf("unexpected result: %+v %v", result, err)
+	}
+
+	if _, err := n.NotifyAndWait(context.Background(), errors.New("Ignored")); err != ErrDropped {
+		t.Errorf("expected the notice to be dropped, got: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package airbrake
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WrapFunc returns a function running f, which reports a panic to airbrake
+// and recovers from it. It is meant for callbacks whose panics would
+// otherwise crash the program, like those of time.AfterFunc or sort.Slice.
+//
+// Example:
+//
+//	time.AfterFunc(time.Minute, airbrake.WrapFunc(cleanup))
+func WrapFunc(f func()) func() {
+	return wrapFunc(defaultNotifier, f)
+}
+
+// WrapFunc works like the package-level WrapFunc.
+func (n *Notifier) WrapFunc(f func()) func() {
+	return wrapFunc(func() *Notifier { return n }, f)
+}
+
+func wrapFunc(notifier func() *Notifier, f func()) func() {
+	return func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				notifier().capture(rec, nil)
+			}
+		}()
+		f()
+	}
+}
+
+// WrapRoundTripper returns a RoundTripper which reports the panics of rt
+// to airbrake and returns them as errors, e.g. for a custom transport.
+func WrapRoundTripper(rt http.RoundTripper) http.RoundTripper {
+	return roundTripper{defaultNotifier, rt}
+}
+
+// WrapRoundTripper works like the package-level WrapRoundTripper.
+func (n *Notifier) WrapRoundTripper(rt http.RoundTripper) http.RoundTripper {
+	return roundTripper{func() *Notifier { return n }, rt}
+}
+
+type roundTripper struct {
+	notifier func() *Notifier
+	next     http.RoundTripper
+}
+
+func (t roundTripper) RoundTrip(r *http.Request) (response *http.Response, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			t.notifier().capture(rec, nil)
+			response, err = nil, fmt.Errorf("airbrake: recovered from panic: %v", rec)
+		}
+	}()
+	return t.next.RoundTrip(r)
+}
+
+// WrapTicker runs f on every tick of the ticker, in a new goroutine, reporting
+// and recovering from its panics, so that a single failure doesn't stop the
+// periodic job. stop stops the ticker and the goroutine.
+//
+// Example:
+//
+//	stop := airbrake.WrapTicker(time.NewTicker(time.Minute), refreshCache)
+//	defer stop()
+func WrapTicker(ticker *time.Ticker, f func()) (stop func()) {
+	return wrapTicker(ticker, wrapFunc(defaultNotifier, f))
+}
+
+// WrapTicker works like the package-level WrapTicker.
+func (n *Notifier) WrapTicker(ticker *time.Ticker, f func()) (stop func()) {
+	return wrapTicker(ticker, n.WrapFunc(f))
+}
+
+func wrapTicker(ticker *time.Ticker, f func()) func() {
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				f()
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
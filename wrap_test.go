@@ -0,0 +1,73 @@
+package airbrake
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// reporter is a notifier collecting the messages of the notices,
+// instead of sending them.
+func reporter() (*Notifier, func() []string) {
+	var mu sync.Mutex
+	var reported []string
+	n := NewNotifier("key", WithFilter(func(notice *Notice) *Notice {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, notice.Error.Message)
+		return nil
+	}))
+	return n, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), reported...)
+	}
+}
+
+func TestWrapFunc(t *testing.T) {
+	n, reported := reporter()
+	n.WrapFunc(func() { panic("Boom!") })()
+
+	if r := reported(); len(r) != 1 || r[0] != "Boom!" {
+		t.Errorf("unexpected notices: %v", r)
+	}
+}
+
+type panickingTransport struct{}
+
+func (panickingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	panic("Boom!")
+}
+
+func TestWrapRoundTripper(t *testing.T) {
+	n, reported := reporter()
+	client := &http.Client{Transport: n.WrapRoundTripper(panickingTransport{})}
+
+	if _, err := client.Get("http://example.com/"); err == nil {
+		t.Error("expected an error")
+	}
+	if r := reported(); len(r) != 1 || r[0] != "Boom!" {
+		t.Errorf("unexpected notices: %v", r)
+	}
+}
+
+func TestWrapTicker(t *testing.T) {
+	n, reported := reporter()
+	ticks := make(chan struct{}, 2)
+	stop := n.WrapTicker(time.NewTicker(time.Millisecond), func() {
+		select {
+		case ticks <- struct{}{}:
+		default:
+		}
+		panic("Boom!")
+	})
+	defer stop()
+
+	<-ticks
+	<-ticks
+	stop()
+	if r := reported(); len(r) < 1 {
+		t.Errorf("unexpected notices: %v", r)
+	}
+}